@@ -0,0 +1,128 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	as "github.com/aerospike/aerospike-client-go/v7"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getAerospikeConfig() (string, int, string, string, string) {
+	return "127.0.0.1", 3000, "test", "wuid", "default"
+}
+
+func connect(t *testing.T) *as.Client {
+	host, port, _, _, _ := getAerospikeConfig()
+	policy := as.NewClientPolicy()
+	policy.Timeout = time.Second * 3
+	client, err := as.NewClientWithPolicy(policy, host, port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestWUID_LoadH28FromAerospike(t *testing.T) {
+	_, _, namespace, set, tag := getAerospikeConfig()
+	client := connect(t)
+	key, _ := as.NewKey(namespace, set, tag)
+	if _, err := client.Delete(nil, key); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromAerospike(client, namespace, set, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromAerospike_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromAerospike(nil, "test", "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromAerospike(&as.Client{}, "", "wuid", "default") == nil {
+		t.Fatal("namespace is not properly checked")
+	}
+	if g.LoadH28FromAerospike(&as.Client{}, "test", "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, _, namespace, set, tag := getAerospikeConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromAerospike(client, namespace, set, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, _, namespace, set, tag := getAerospikeConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromAerospike(client, namespace, set, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *as.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromAerospike(client, "test", "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
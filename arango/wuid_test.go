@@ -0,0 +1,145 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/arangodb/go-driver/http"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getArangoConfig() (string, string, string, string) {
+	return "http://127.0.0.1:8529", "_system", "wuid", "default"
+}
+
+func connect(t *testing.T) (driver.Database, string, string) {
+	endpoint, database, collection, tag := getArangoConfig()
+	conn, err := http.NewConnection(http.ConnectionConfig{Endpoints: []string{endpoint}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := driver.NewClient(driver.ClientConfig{
+		Connection:     conn,
+		Authentication: driver.BasicAuthentication("root", ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	db, err := client.Database(ctx, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := db.CollectionExists(ctx, collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		if _, err = db.CreateCollection(ctx, collection, nil); err != nil && !driver.IsConflict(err) {
+			t.Fatal(err)
+		}
+	}
+	return db, collection, tag
+}
+
+func TestWUID_LoadH28FromArango(t *testing.T) {
+	db, collection, tag := connect(t)
+	col, err := db.Collection(context.Background(), collection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = col.RemoveDocument(context.Background(), tag)
+	if err != nil && !driver.IsNotFoundGeneral(err) {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromArango(db, collection, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromArango_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromArango(nil, "wuid", "default") == nil {
+		t.Fatal("db is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	db, collection, tag := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromArango(db, collection, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	db, collection, tag := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromArango(db, collection, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var db driver.Database
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromArango(db, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
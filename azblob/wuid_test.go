@@ -0,0 +1,179 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+// The well-known development account of Azurite.
+const azuriteConnectionString = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
+func getAzureBlobConfig() (string, string, string) {
+	return azuriteConnectionString, "wuid", "default"
+}
+
+func connect(connectionString, containerName string) (*azblob.Client, error) {
+	client, err := azblob.NewClientFromConnectionString(connectionString, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	_, err = client.CreateContainer(ctx, containerName, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+		return nil, err
+	}
+	return client, nil
+}
+
+func TestWUID_LoadH28FromAzureBlob(t *testing.T) {
+	connectionString, containerName, blobName := getAzureBlobConfig()
+	client, err := connect(connectionString, containerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.DeleteBlob(context.Background(), containerName, blobName, nil)
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromAzureBlob(client, containerName, blobName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromAzureBlob_Concurrent(t *testing.T) {
+	connectionString, containerName, blobName := getAzureBlobConfig()
+	client, err := connect(connectionString, containerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromAzureBlob(client, containerName, blobName); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			seen[atomic.LoadUint64(&g.w.N)>>36] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if !t.Failed() && len(seen) != n {
+		t.Fatalf("duplicate h28 detected: %d unique values out of %d", len(seen), n)
+	}
+}
+
+func TestWUID_LoadH28FromAzureBlob_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromAzureBlob(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromAzureBlob(&azblob.Client{}, "", "default") == nil {
+		t.Fatal("containerName is not properly checked")
+	}
+	if g.LoadH28FromAzureBlob(&azblob.Client{}, "wuid", "") == nil {
+		t.Fatal("blobName is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	connectionString, containerName, blobName := getAzureBlobConfig()
+	client, err := connect(connectionString, containerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromAzureBlob(client, containerName, blobName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	connectionString, containerName, blobName := getAzureBlobConfig()
+	client, err := connect(connectionString, containerName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromAzureBlob(client, containerName, blobName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *azblob.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromAzureBlob(client, "my-container", "wuid/default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,782 @@
+/*
+Package wuid provides WUID, an extremely fast unique number generator. It is 10-135 times faster
+than UUID and 4600 times faster than generating unique numbers with Redis.
+
+WUID generates unique 64-bit integers in sequence. The high 28 bits are loaded from a data store.
+By now, Redis, MySQL, PostgreSQL, and MongoDB are supported.
+*/
+package wuid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	bolt "go.etcd.io/bbolt"
+)
+
+/*
+Logger includes internal.Logger, while internal.Logger includes:
+	Info(args ...interface{})
+	Warn(args ...interface{})
+*/
+type Logger interface {
+	internal.Logger
+}
+
+// WUID is an extremely fast unique number generator.
+type WUID struct {
+	w *internal.WUID
+}
+
+// NewWUID creates a new WUID instance.
+func NewWUID(tag string, logger Logger, opts ...Option) *WUID {
+	var opts2 []internal.Option
+	for _, opt := range opts {
+		opts2 = append(opts2, internal.Option(opt))
+	}
+	return &WUID{w: internal.NewWUID(tag, logger, opts2...)}
+}
+
+// Next returns the next unique number.
+func (this *WUID) Next() uint64 {
+	return this.w.Next()
+}
+
+var (
+	// ErrExhausted is returned by NextE when the low bits have run out before a renew succeeds.
+	ErrExhausted = internal.ErrExhausted
+	// ErrNotLoaded is returned by NextE when the high bits have not been loaded yet.
+	ErrNotLoaded = internal.ErrNotLoaded
+	// ErrClosed is returned by NextE and NextCtx when WUID has been closed.
+	ErrClosed = internal.ErrClosed
+	// ErrRenewTimeout is returned by RenewNow when the renew does not finish within the renew timeout.
+	ErrRenewTimeout = internal.ErrRenewTimeout
+	// ErrBreakerOpen is returned by RenewNow when the circuit breaker is open.
+	ErrBreakerOpen = internal.ErrBreakerOpen
+	// ErrRenewFailing is returned by Healthy when the low bits are running out and the renew keeps
+	// failing.
+	ErrRenewFailing = internal.ErrRenewFailing
+)
+
+// NextE works like Next, but returns an error instead of panicking, so that the callers in the
+// request paths can degrade gracefully. Use errors.Is to tell ErrExhausted, ErrNotLoaded and
+// ErrClosed apart.
+func (this *WUID) NextE() (uint64, error) {
+	return this.w.NextE()
+}
+
+// NextN returns n consecutive unique numbers, which are reserved with a single atomic operation.
+func (this *WUID) NextN(n int) []uint64 {
+	return this.w.NextN(n)
+}
+
+// AppendNextN appends n consecutive unique numbers to dst and returns the extended slice.
+func (this *WUID) AppendNextN(dst []uint64, n int) []uint64 {
+	return this.w.AppendNextN(dst, n)
+}
+
+// NextCtx returns the next unique number. Unlike Next, which panics when the low 36 bits run out,
+// it waits until a renew succeeds, or until ctx is done, in which case it returns ctx.Err().
+func (this *WUID) NextCtx(ctx context.Context) (uint64, error) {
+	return this.w.NextCtx(ctx)
+}
+
+// NextWithSection returns the next unique number stamped with a per-call section ID, e.g. to label
+// the numbers by subsystem with a single WUID. It requires WithSectionWidth, and panics if the
+// section ID does not fit in the section width.
+func (this *WUID) NextWithSection(section uint8) uint64 {
+	return this.w.NextWithSection(section)
+}
+
+// Peek returns the number that the next call to Next will return, without consuming it. It is
+// only a snapshot when there are other goroutines calling Next, e.g. for debugging, metrics and
+// checkpointing.
+func (this *WUID) Peek() uint64 {
+	return this.w.Peek()
+}
+
+// LastIssued returns the number that was generated last, or 0 if no number has been generated
+// since the high 28 bits were loaded.
+func (this *WUID) LastIssued() uint64 {
+	return this.w.LastIssued()
+}
+
+// Stats holds the state of a WUID. See the fields for details.
+type Stats = internal.Stats
+
+// Stats returns the current h28, how many numbers have been generated and how many remain in the
+// current block, as well as the renew history, e.g. for dashboards.
+func (this *WUID) Stats() Stats {
+	return this.w.Stats()
+}
+
+// Healthy returns nil if the generator can keep handing out numbers, e.g. for readiness probes.
+// Otherwise it returns an error wrapping ErrClosed, ErrNotLoaded, ErrBreakerOpen, ErrExhausted, or
+// ErrRenewFailing if the current block is past the renew threshold and the last renew failed.
+func (this *WUID) Healthy() error {
+	return this.w.Healthy()
+}
+
+// Timestamp returns when a unique number was generated, which requires WithTimePrefix or
+// WithSnowflake. With WithTimePrefix, it is the start of the time unit.
+func (this *WUID) Timestamp(id uint64) (time.Time, error) {
+	return this.w.Timestamp(id)
+}
+
+// IDRangeForInterval returns the smallest and the largest unique numbers that can be generated
+// between from and to, inclusively, which requires WithTimePrefix or WithSnowflake. Filtering
+// by the range, e.g. WHERE id BETWEEN lo AND hi, can replace an index on the creation time in
+// the analytics queries. The range is only as precise as the time unit.
+func (this *WUID) IDRangeForInterval(from, to time.Time) (lo, hi uint64, err error) {
+	return this.w.IDRangeForInterval(from, to)
+}
+
+// ReserveRange allocates n contiguous unique numbers at once, e.g. for the offline imports and ETL
+// jobs that pre-assign millions of IDs, and returns the first and the last of them. The range is
+// taken from the current block if it fits, or otherwise from fresh h28 values reserved from your
+// data store in bulk. LoadH28FromBolt supports the latter.
+func (this *WUID) ReserveRange(n uint64) (start, end uint64, err error) {
+	return this.w.ReserveRange(n)
+}
+
+// NextString returns the next unique number encoded by EncodeBase62, or by the alphabet specified
+// with WithAlphabet. WithCheckDigit appends a check digit to it.
+func (this *WUID) NextString() string {
+	return this.w.NextString()
+}
+
+// EncodeBase62 encodes n into a short URL-safe string with the characters 0-9, A-Z and a-z.
+func EncodeBase62(n uint64) string {
+	return internal.EncodeBase62(n)
+}
+
+// DecodeBase62 decodes a string produced by EncodeBase62.
+func DecodeBase62(s string) (uint64, error) {
+	return internal.DecodeBase62(s)
+}
+
+// EncodeBase62Check works like EncodeBase62, but appends a Luhn mod 62 check digit, which catches
+// every single-character typo and most of the swapped adjacent characters.
+func EncodeBase62Check(n uint64) string {
+	return internal.EncodeBase62Check(n)
+}
+
+// DecodeBase62Check decodes a string produced by EncodeBase62Check, and rejects it if the check
+// digit does not match.
+func DecodeBase62Check(s string) (uint64, error) {
+	return internal.DecodeBase62Check(s)
+}
+
+// Alphabet encodes unique numbers into strings with a custom set of characters.
+type Alphabet = internal.Alphabet
+
+// NewAlphabet creates an Alphabet. The characters must be printable ASCII characters without
+// duplicates, and must not include more than one of the ambiguous ones, e.g. 0 and O, or 1 and l.
+func NewAlphabet(chars string) (*Alphabet, error) {
+	return internal.NewAlphabet(chars)
+}
+
+// Obfuscator maps numbers to other numbers with a keyed, reversible permutation.
+type Obfuscator = internal.Obfuscator
+
+// NewObfuscator creates an Obfuscator with a 16-byte key.
+func NewObfuscator(key []byte) (*Obfuscator, error) {
+	return internal.NewObfuscator(key)
+}
+
+// Deobfuscate recovers the original number from a number generated with WithObfuscation(key).
+func Deobfuscate(key []byte, n uint64) (uint64, error) {
+	o, err := internal.NewObfuscator(key)
+	if err != nil {
+		return 0, err
+	}
+	return o.Deobfuscate(n), nil
+}
+
+// NextPrefixed returns the next unique number as a self-describing string, e.g. ord_ooR2glN,
+// which is the prefix set by WithPrefix, an underscore and the base62 form of the number, plus a
+// check digit with WithCheckDigit. It panics if WithPrefix is not used.
+func (this *WUID) NextPrefixed() string {
+	return this.w.NextPrefixed()
+}
+
+// FormatPrefixed formats n into the same form as NextPrefixed does with the specified prefix.
+func FormatPrefixed(prefix string, n uint64) string {
+	return internal.FormatPrefixed(prefix, n)
+}
+
+// ParsePrefixed checks that s starts with the specified prefix and an underscore, and returns
+// the number encoded in the rest of it.
+func ParsePrefixed(prefix, s string) (uint64, error) {
+	return internal.ParsePrefixed(prefix, s)
+}
+
+// FormatPrefixedCheck works like FormatPrefixed, but appends a check digit like
+// EncodeBase62Check does.
+func FormatPrefixedCheck(prefix string, n uint64) string {
+	return internal.FormatPrefixedCheck(prefix, n)
+}
+
+// ParsePrefixedCheck parses a string produced by FormatPrefixedCheck, and rejects it if the
+// check digit does not match.
+func ParsePrefixedCheck(prefix, s string) (uint64, error) {
+	return internal.ParsePrefixedCheck(prefix, s)
+}
+
+// NextCrockford returns the next unique number encoded by EncodeCrockford.
+func (this *WUID) NextCrockford() string {
+	return this.w.NextCrockford()
+}
+
+// EncodeCrockford encodes n into a Crockford base32 string, which has no ambiguous characters and
+// is easy for humans to read over the phone or to type.
+func EncodeCrockford(n uint64) string {
+	return internal.EncodeCrockford(n)
+}
+
+// DecodeCrockford decodes a Crockford base32 string case-insensitively. Hyphens are ignored, and
+// I, L and O are taken as 1, 1 and 0, respectively.
+func DecodeCrockford(s string) (uint64, error) {
+	return internal.DecodeCrockford(s)
+}
+
+// NextULID returns the next unique number as a 26-character ULID-compatible string. The highest
+// 48 bits hold the current Unix time in milliseconds, the next 16 bits are 0, and the lowest 64
+// bits hold the unique number, so the strings sort by time first.
+func (this *WUID) NextULID() string {
+	return this.w.NextULID()
+}
+
+// FormatULID formats a Unix time in milliseconds and a unique number into a ULID-compatible
+// string. Pass 0 as ms to omit the timestamp.
+func FormatULID(ms uint64, n uint64) string {
+	return internal.FormatULID(ms, n)
+}
+
+// ParseULID parses a string produced by FormatULID or NextULID.
+func ParseULID(s string) (ms uint64, n uint64, err error) {
+	return internal.ParseULID(s)
+}
+
+// UUID is an RFC 4122 shaped value that embeds a unique number. It works with UUID columns in
+// databases as it is.
+type UUID = internal.UUID
+
+// NextUUID returns the next unique number embedded in a UUID of version 8, i.e. a custom UUID
+// defined by RFC 9562. The UUIDs sort in the same order as the numbers, and the bits that are
+// left are padded with 0. Use UUID.Uint64 to recover the number.
+func (this *WUID) NextUUID() UUID {
+	return this.w.NextUUID()
+}
+
+// NewUUID embeds n in a UUID in the same way as NextUUID.
+func NewUUID(n uint64) UUID {
+	return internal.NewUUID(n)
+}
+
+// ParseUUID parses a UUID in the canonical form, e.g. 000002a0-0000-8000-8400-000000000000.
+func ParseUUID(s string) (UUID, error) {
+	return internal.ParseUUID(s)
+}
+
+// ID is a unique number generated by WUID, with methods that tell its parts apart.
+type ID = internal.ID
+
+// NextID returns the next unique number as an ID.
+func (this *WUID) NextID() ID {
+	return this.w.NextID()
+}
+
+// StringID is the same as ID except that it is encoded as a JSON string, so that JavaScript
+// clients do not lose precision. Both forms are accepted when it is decoded.
+type StringID = internal.StringID
+
+// Decompose splits a unique number into its section ID, its high 28 bits and its low 36 bits,
+// with the default layout only. The highest 4 bits are taken as the section ID if they are not 0,
+// in which case h28 holds the 24 bits below them. The method Decompose follows the other layouts.
+func Decompose(id uint64) (section uint8, h28 uint64, seq uint64) {
+	return internal.Decompose(id)
+}
+
+// Layout holds the parts of a unique number. Its String method explains them.
+type Layout = internal.Layout
+
+// Explain returns the Layout of a unique number, e.g. for logging while debugging. Like the
+// function Decompose, it assumes the default layout.
+func Explain(id uint64) Layout {
+	return internal.Explain(id)
+}
+
+// BoundsForH28 returns the smallest and the largest numbers in the block of an h28 and a section
+// ID, or 0 for none, with the default layout only. Every number generated with the h28 falls in
+// the range, and the blocks of consecutive h28 values are adjacent, so that the tables can be
+// partitioned and the shards can be pre-split by ID range deterministically. The method
+// BoundsForH28 follows the other layouts.
+func BoundsForH28(h28 uint64, section uint8) (lo, hi uint64, err error) {
+	return internal.BoundsForH28(h28, section)
+}
+
+// LayoutVersion returns the version stamped by WithLayoutVersion, with the default layout only.
+// The method LayoutVersion follows the other layouts.
+func LayoutVersion(id uint64) uint8 {
+	return internal.LayoutVersion(id)
+}
+
+// Decompose works like the function Decompose, but follows the layout of the generator, e.g.
+// WithBitLayout, WithSectionWidth and WithLayoutVersion. Obfuscated numbers are deobfuscated first.
+func (this *WUID) Decompose(id uint64) (section uint8, h28 uint64, seq uint64) {
+	return this.w.Decompose(id)
+}
+
+// BoundsForH28 works like the function BoundsForH28, but follows the layout of the generator and
+// uses its section ID. It fails with WithObfuscation, WithTimePrefix and WithSnowflake, under
+// which the numbers of a block do not form a range.
+func (this *WUID) BoundsForH28(h28 uint64) (lo, hi uint64, err error) {
+	return this.w.BoundsForH28(h28)
+}
+
+// LayoutVersion works like the function LayoutVersion, but follows the layout of the generator.
+// It returns 0 without WithLayoutVersion.
+func (this *WUID) LayoutVersion(id uint64) uint8 {
+	return this.w.LayoutVersion(id)
+}
+
+// SnowflakeEpoch is the epoch of Twitter Snowflake, which WithSnowflake uses by default.
+var SnowflakeEpoch = internal.SnowflakeEpoch
+
+// DecomposeSnowflake splits a unique number generated with WithSnowflake(epoch) into its
+// timestamp, its worker ID and its sequence number.
+func DecomposeSnowflake(id uint64, epoch time.Time) (t time.Time, worker uint64, seq uint64) {
+	return internal.DecomposeSnowflake(id, epoch)
+}
+
+// TimePrefixEpoch is the starting point of the timestamps added by WithTimePrefix.
+var TimePrefixEpoch = internal.TimePrefixEpoch
+
+// WUID128 generates 128-bit unique numbers. The high 64 bits are a unique number taken from a
+// loaded WUID, and the low 64 bits are incremented by Next, so it does not need to renew at all.
+type WUID128 struct {
+	w *internal.WUID128
+}
+
+// NewWUID128 creates a new WUID128 instance. g must have loaded its high 28 bits from your data
+// store. It takes one unique number from g each time it is called.
+func NewWUID128(g *WUID) (*WUID128, error) {
+	if g == nil {
+		return nil, errors.New("g cannot be nil")
+	}
+	w, err := internal.NewWUID128(g.w)
+	if err != nil {
+		return nil, err
+	}
+	return &WUID128{w: w}, nil
+}
+
+// Next returns the next 128-bit unique number.
+func (this *WUID128) Next() ID128 {
+	return this.w.Next()
+}
+
+// ID128 is a 128-bit unique number generated by WUID128.
+type ID128 = internal.ID128
+
+// ID128FromBytes decodes an ID128 from 16 bytes in big-endian order.
+func ID128FromBytes(b [16]byte) ID128 {
+	return internal.ID128FromBytes(b)
+}
+
+// Typed generates unique numbers of a domain-specific type, e.g. type OrderID uint64, so that
+// there is no need to convert them at every call site. If T is based on int64, consider
+// WithSignedSafe, or the numbers may be negative.
+type Typed[T ~uint64 | ~int64] struct {
+	g *WUID
+}
+
+// NewTyped creates a Typed instance that takes the numbers from g.
+func NewTyped[T ~uint64 | ~int64](g *WUID) *Typed[T] {
+	return &Typed[T]{g: g}
+}
+
+// Next returns the next unique number as a T.
+func (this *Typed[T]) Next() T {
+	return T(this.g.Next())
+}
+
+// NextN returns n consecutive unique numbers as Ts, which are reserved with a single atomic
+// operation.
+func (this *Typed[T]) NextN(n int) []T {
+	a := this.g.NextN(n)
+	if a == nil {
+		return nil
+	}
+	b := make([]T, len(a))
+	for i, x := range a {
+		b[i] = T(x)
+	}
+	return b
+}
+
+// LoadH28FromBolt adds 1 to the counter of the tag in a specific bucket of your bolt database, and
+// then sets the new value as the high 28 bits of the unique numbers that Next generates.
+// The bucket is created automatically if it does not exist.
+func (this *WUID) LoadH28FromBolt(db *bolt.DB, bucket string) error {
+	if db == nil {
+		return errors.New("db cannot be nil. tag: " + this.w.Tag)
+	}
+	if len(bucket) == 0 {
+		return errors.New("bucket cannot be empty. tag: " + this.w.Tag)
+	}
+
+	h28, err := incr(db, bucket, this.w.Tag, 1)
+	if err != nil {
+		return err
+	}
+	if err = this.w.VerifyH28(h28); err != nil {
+		return err
+	}
+
+	this.w.ResetH28(h28)
+	this.w.Logger.Info(fmt.Sprintf("<wuid> new h28: %d. tag: %s", h28, this.w.Tag))
+
+	this.w.Lock()
+	defer this.w.Unlock()
+
+	if this.w.Renew != nil {
+		return nil
+	}
+	this.w.Renew = func() error {
+		return this.LoadH28FromBolt(db, bucket)
+	}
+	this.w.ReserveH28 = func(k uint64) (uint64, error) {
+		return incr(db, bucket, this.w.Tag, k)
+	}
+
+	return nil
+}
+
+func incr(db *bolt.DB, bucket, tag string, k uint64) (uint64, error) {
+	var h28 uint64
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		var n uint64
+		if v := b.Get([]byte(tag)); v != nil {
+			n, err = strconv.ParseUint(string(v), 10, 64)
+			if err != nil {
+				return fmt.Errorf("the counter of %s is not a number: %s", tag, err)
+			}
+		}
+		h28 = n + k
+		return b.Put([]byte(tag), []byte(strconv.FormatUint(h28, 10)))
+	})
+	return h28, err
+}
+
+// RenewNow reacquires the high 28 bits from your data store immediately
+func (this *WUID) RenewNow() error {
+	return this.w.RenewNow()
+}
+
+// Close releases the resources that WUID holds, and disables renew. Next panics and NextCtx returns
+// an error after it is called. The connections of your application are never closed by WUID.
+func (this *WUID) Close() error {
+	return this.w.Close()
+}
+
+// Snapshot serializes the counter, the tag, the section ID and the layout of WUID, so that the
+// progress can be persisted locally and restored after a restart. Take it after the last number
+// is generated, e.g. after calling Close. Otherwise the numbers generated after it are generated
+// again once it is restored.
+func (this *WUID) Snapshot() []byte {
+	return this.w.Snapshot()
+}
+
+// Restore continues from a snapshot taken by Snapshot instead of loading a fresh h28 from your
+// data store. It must be called before WUID is loaded, with the same tag and options. renew is
+// called when the restored numbers are about to run out, and is typically a wrapper of one of the
+// loaders.
+func (this *WUID) Restore(data []byte, renew func() error) error {
+	return this.w.Restore(data, renew)
+}
+
+// Registry owns multiple generators, one per tag, which typically share one backend connection.
+type Registry struct {
+	r *internal.Registry[*WUID]
+}
+
+// NewRegistry creates a new Registry. The generators are created lazily by Get, with logger and
+// opts, and load is called to load their high 28 bits, e.g. from a key derived from the tag.
+func NewRegistry(logger Logger, load func(g *WUID, tag string) error, opts ...Option) *Registry {
+	newFn := func(tag string) *WUID {
+		return NewWUID(tag, logger, opts...)
+	}
+	unwrap := func(g *WUID) *internal.WUID {
+		return g.w
+	}
+	return &Registry{r: internal.NewRegistry(newFn, load, unwrap)}
+}
+
+// Get returns the generator of tag. It is created and loaded on the first call. A failed load is
+// not cached, so the next call tries again.
+func (this *Registry) Get(tag string) (*WUID, error) {
+	return this.r.Get(tag)
+}
+
+// Tags returns the tags of the loaded generators in sorted order.
+func (this *Registry) Tags() []string {
+	return this.r.Tags()
+}
+
+// RenewAll reacquires the high 28 bits of all loaded generators immediately. The errors are
+// joined together.
+func (this *Registry) RenewAll() error {
+	return this.r.RenewAll()
+}
+
+// CloseAll closes all generators and drops them. Get fails after it is called. The errors are
+// joined together.
+func (this *Registry) CloseAll() error {
+	return this.r.CloseAll()
+}
+
+// Option should never be used directly.
+type Option internal.Option
+
+// WithSection adds a section ID to the generated numbers. The section ID must be in between [1, 15].
+// It occupies the highest 4 bits of the numbers.
+func WithSection(section uint8) Option {
+	return Option(internal.WithSection(section))
+}
+
+// WithSectionWidth reserves the highest bits of the generated numbers, from 1 to 8, for section
+// IDs, so that NextWithSection can stamp them per call. The section ID added by WithSection, if
+// any, must fit in the width, and the high bits loaded from your data store are reduced
+// accordingly.
+func WithSectionWidth(bits uint8) Option {
+	return Option(internal.WithSectionWidth(bits))
+}
+
+// WithRegion reserves 1 to 8 bits right below the section ID, if any, for a fixed region ID, e.g.
+// a datacenter. The generators in different regions can then share a tag and even diverged data
+// stores without colliding. The region ID must fit in the width, otherwise it panics, and the
+// high bits loaded from your data store are reduced accordingly.
+func WithRegion(id uint8, bits uint8) Option {
+	return Option(internal.WithRegion(id, bits))
+}
+
+// WithTimePrefix puts a coarse timestamp, the number of units since TimePrefixEpoch, in the
+// highest bits of the generated numbers, so that they are roughly sortable by creation time, e.g.
+// for range scans and pagination. WithTimePrefix(16, time.Hour) lasts for about 7 years before
+// it wraps around. The high bits loaded from your data store are reduced accordingly. bits must
+// be in between [1, 40] and unit must not be less than 1 millisecond, otherwise it panics.
+func WithTimePrefix(bits uint8, unit time.Duration) Option {
+	return Option(internal.WithTimePrefix(bits, unit))
+}
+
+// WithLayoutVersion reserves the highest 2 bits of the generated numbers for a layout version, in
+// between [0, 3], so that the numbers of a future layout can coexist with the existing ones. The
+// other fixed fields, e.g. the section ID, are moved down, and the high bits loaded from your data
+// store are reduced accordingly. It panics if version is out of range.
+func WithLayoutVersion(version uint8) Option {
+	return Option(internal.WithLayoutVersion(version))
+}
+
+// WithSnowflake makes the generator emit the numbers in the Twitter Snowflake layout: a 0 sign
+// bit, 41 bits of milliseconds since epoch, 10 worker bits and a 12-bit sequence number, so that
+// the numbers stay sortable by time. If epoch is the zero time, SnowflakeEpoch is used. The worker
+// ID is the lowest 10 bits of the h28 loaded from your data store, so the worker IDs stay unique
+// as long as the h28 values of the running generators are less than 1024 apart. It cannot be
+// combined with the section, region, layout, step, skip and obfuscation options, otherwise
+// NewWUID panics.
+func WithSnowflake(epoch time.Time) Option {
+	return Option(internal.WithSnowflake(epoch))
+}
+
+// WithJSSafe shrinks the generated numbers to 53 bits, so that they never exceed 2^53-1 and are
+// safe to be used as JavaScript numbers. The high bits that are loaded from your data store are
+// reduced to 21, and the low bits to 32. If a section ID is added, it occupies the highest 4 of
+// the 53 bits.
+func WithJSSafe() Option {
+	return Option(internal.WithJSSafe())
+}
+
+// WithBitLayout splits the generated numbers into hBits high bits, which are loaded from your
+// data store, and lowBits low bits, which are incremented by Next. The default is 28 and 36. More
+// low bits mean fewer renews, while more high bits tolerate more restarts. lowBits must be in
+// between [20, 56], hBits must not be less than 8, and their sum must not exceed 64. The bound of
+// the h28 verification and the position of the section ID follow the layout.
+func WithBitLayout(hBits, lowBits uint8) Option {
+	return Option(internal.WithBitLayout(hBits, lowBits))
+}
+
+// WithSignedSafe shrinks the generated numbers to 63 bits, so that the highest bit is never set,
+// and they never appear negative when stored as signed 64-bit integers, e.g. in BIGINT columns or
+// Java longs. The high bits that are loaded from your data store are reduced to 27. If a section
+// ID is added, it occupies the highest 4 of the 63 bits.
+func WithSignedSafe() Option {
+	return Option(internal.WithSignedSafe())
+}
+
+// WithStep makes consecutive numbers differ by step rather than 1, e.g. to interleave with a legacy
+// sequence or to reserve the numbers in between for other purposes. The step must be in between
+// [1, 16384].
+func WithStep(step uint64) Option {
+	return Option(internal.WithStep(step))
+}
+
+// WithSkipValues makes the generator never return the specified values, e.g. the sentinel values
+// that your ORM treats specially. Note that 0 is never generated anyway.
+func WithSkipValues(values ...uint64) Option {
+	return Option(internal.WithSkipValues(values...))
+}
+
+// WithSkipAllOnes makes the generator never return numbers whose lowest bits are all ones, e.g.
+// 0x00000123FFFFFFFF when bits is 32, which some systems take as overflow markers.
+func WithSkipAllOnes(bits uint8) Option {
+	return Option(internal.WithSkipAllOnes(bits))
+}
+
+// WithSkipFunc makes the generator never return the numbers for which skip returns true. It can
+// be used together with WithSkipValues and WithSkipAllOnes. skip should be fast, because it is
+// called for every number.
+func WithSkipFunc(skip func(n uint64) bool) Option {
+	return Option(internal.WithSkipFunc(skip))
+}
+
+// WithAlphabet makes NextString encode the numbers with the specified characters rather than the
+// base62 ones. It panics if the characters are rejected by NewAlphabet. Use NewAlphabet with the
+// same characters to decode the strings.
+func WithAlphabet(chars string) Option {
+	return Option(internal.WithAlphabet(chars))
+}
+
+// WithObfuscation makes the generator pass every number through a keyed, reversible permutation
+// before returning it, so that the numbers no longer reveal the h28 value or the issue order. The
+// key must be 16 bytes long. Use Deobfuscate with the same key to recover the original numbers.
+// It works only with the 64-bit layouts and cannot be combined with WithSectionWidth.
+func WithObfuscation(key []byte) Option {
+	return Option(internal.WithObfuscation(key))
+}
+
+// WithPrefix sets the prefix of the strings returned by NextPrefixed, e.g. ord or user. The
+// prefix must be 1 to 16 characters long, start with a lowercase letter and contain only
+// lowercase letters and digits, otherwise it panics.
+func WithPrefix(prefix string) Option {
+	return Option(internal.WithPrefix(prefix))
+}
+
+// WithCheckDigit makes NextString and NextPrefixed append a check digit to the strings, so that
+// the mistyped IDs, e.g. entered by support staff, are caught before hitting the database. Decode
+// the strings with DecodeBase62Check, ParsePrefixedCheck or Alphabet.DecodeCheck accordingly.
+func WithCheckDigit() Option {
+	return Option(internal.WithCheckDigit())
+}
+
+// WithRotation makes the generator renew at every multiple of interval since the zero time, in
+// addition to when the low bits are about to run out. For example, WithRotation(24 * time.Hour)
+// renews at midnight UTC every day, so that the numbers generated on different days have
+// different h28 values. The interval must not be less than 1 second, otherwise it panics.
+func WithRotation(interval time.Duration) Option {
+	return Option(internal.WithRotation(interval))
+}
+
+// WithRenewThreshold sets how much of the low bits may be consumed before the background renew
+// fires, e.g. 0.5 for high-throughput services and 0.99 for low-throughput ones, which burn fewer
+// h28 values. The default is 0.8. The fraction must be in between [0.01, 0.99], otherwise it panics.
+func WithRenewThreshold(fraction float64) Option {
+	return Option(internal.WithRenewThreshold(fraction))
+}
+
+// WithRenewTimeout makes every renew give up after d, so that a hung connection to your data store
+// cannot stall the renew forever. RenewNow returns an error wrapping ErrRenewTimeout then, and the
+// renew is retried just like any other failed one. The abandoned call keeps running in the
+// background until your data store responds. d must be positive, otherwise it panics.
+func WithRenewTimeout(d time.Duration) Option {
+	return Option(internal.WithRenewTimeout(d))
+}
+
+// WithCircuitBreaker makes the generator stop calling your data store after the specified number
+// of renews have failed in a row. The renews fail with ErrBreakerOpen instead, until the cool-down
+// is over. Then one renew per cool-down is let through, and the breaker closes once one succeeds.
+// Both failures and cooldown must be positive, otherwise it panics.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return Option(internal.WithCircuitBreaker(failures, cooldown))
+}
+
+// WithPrefetch makes the generator hold a prefetched block in addition to the current one. The
+// background renew fills the spare block instead of replacing the current one, and the generator
+// switches to it instantly once the current one runs out, refilling the spare in the background.
+// It cannot be combined with the Snowflake layout or WithRotation, otherwise NewWUID panics.
+func WithPrefetch() Option {
+	return Option(internal.WithPrefetch())
+}
+
+// WithBlocksPerRenew makes every round trip to your data store reserve n consecutive h28 values
+// instead of one, and the next n-1 renews use them without a round trip. It cuts the renew
+// frequency by n at the cost of consuming the h28 values faster, e.g. when the process restarts. It
+// only works with the loaders that can reserve h28 values in bulk, see ReserveRange. The other
+// loaders fail with it, and so does the renew passed to Restore. n must be positive, otherwise it
+// panics.
+func WithBlocksPerRenew(n int) Option {
+	return Option(internal.WithBlocksPerRenew(n))
+}
+
+// Usage describes how much of the block in use was consumed when a WUID was closed. See the fields
+// for details.
+type Usage = internal.Usage
+
+// WithUsageReport sets a callback that is called once when the generator is closed, with how much
+// of the current block was consumed and how much remains, e.g. to write it back to your data store
+// for capacity accounting. It is called before the resources of the WUID are released, and its
+// error is returned by Close. Nothing is reported if the WUID has never been loaded or the
+// Snowflake layout is used.
+func WithUsageReport(report func(u Usage) error) Option {
+	return Option(internal.WithUsageReport(report))
+}
+
+// ExhaustionPolicy controls what Next does when the low bits run out before a renew succeeds.
+type ExhaustionPolicy = internal.ExhaustionPolicy
+
+const (
+	// ExhaustionPanic makes Next panic with a string, which is the default.
+	ExhaustionPanic = internal.ExhaustionPanic
+	// ExhaustionError still makes Next panic, but with an error wrapping ErrExhausted or ErrClosed,
+	// which can be told apart with errors.Is after recovering. Use NextE to get the error returned
+	// instead.
+	ExhaustionError = internal.ExhaustionError
+	// ExhaustionBlock makes Next wait for a renew like NextCtx, and panic with an error wrapping
+	// ErrExhausted if none succeeds within the timeout.
+	ExhaustionBlock = internal.ExhaustionBlock
+)
+
+// WithExhaustionPolicy sets what Next, NextN and AppendNextN do when the low bits run out before a
+// renew succeeds. timeout is how long ExhaustionBlock waits, and is ignored by the other policies.
+// It panics if the policy is unknown, or if the timeout of ExhaustionBlock is not positive.
+func WithExhaustionPolicy(policy ExhaustionPolicy, timeout time.Duration) Option {
+	return Option(internal.WithExhaustionPolicy(policy, timeout))
+}
+
+// WithH28Verifier sets your own h28 verifier
+func WithH28Verifier(cb func(h28 uint64) error) Option {
+	return Option(internal.WithH28Verifier(cb))
+}
+
+// WithOnRenew sets a callback that is called after every successful renew with the h28 values
+// before and after it, e.g. to record the allocation in your own audit table. It runs on the
+// goroutine that performs the renew, so it should return quickly.
+func WithOnRenew(cb func(oldH28, newH28 uint64)) Option {
+	return Option(internal.WithOnRenew(cb))
+}
+
+// WithOnRenewError sets a callback that is called after every failed renew with the current h28
+// and the error, e.g. to page someone. It runs on the goroutine that performs the renew, so it
+// should return quickly.
+func WithOnRenewError(cb func(h28 uint64, err error)) Option {
+	return Option(internal.WithOnRenewError(cb))
+}
@@ -0,0 +1,13 @@
+//go:build go1.23
+
+package wuid
+
+import (
+	"iter"
+)
+
+// All returns an iterator over the unique numbers generated by Next, so that you can range over
+// them. Breaking out of the loop stops the iteration without consuming any more numbers.
+func (this *WUID) All() iter.Seq[uint64] {
+	return this.w.All()
+}
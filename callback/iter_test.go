@@ -0,0 +1,30 @@
+//go:build go1.23
+
+package wuid
+
+import (
+	"testing"
+)
+
+func TestWUID_All(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	var last uint64
+	for id := range g.All() {
+		if last != 0 && id != last+1 {
+			t.Fatalf("All does not work as expected: %x, %x", last, id)
+		}
+		last = id
+		if id == 42<<36+10 {
+			break
+		}
+	}
+	if n := g.Next(); n != 42<<36+11 {
+		t.Fatalf("All does not work as expected: %x", n)
+	}
+}
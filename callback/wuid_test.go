@@ -1,6 +1,7 @@
 package wuid
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type simpleLogger struct{}
@@ -38,6 +40,13 @@ func TestWUID_LoadH28WithCallback_Error(t *testing.T) {
 	if err == nil {
 		t.Fatal("LoadH28WithCallback should fail when cb returns an invalid h28")
 	}
+
+	err = NewWUID("default", sl, WithBlocksPerRenew(4)).LoadH28WithCallback(func() (uint64, func(), error) {
+		return 1, nil, nil
+	})
+	if err == nil {
+		t.Fatal("LoadH28WithCallback should fail with WithBlocksPerRenew")
+	}
 }
 
 func TestWUID_LoadH28WithCallback(t *testing.T) {
@@ -137,3 +146,709 @@ func Example() {
 		fmt.Printf("%#016x\n", g.Next())
 	}
 }
+
+func TestRegistry(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	reg := NewRegistry(sl, func(g *WUID, tag string) error {
+		return g.LoadH28WithCallback(cb)
+	}, WithSection(1))
+
+	g1, err := reg.Get("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := reg.Get("beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g, _ := reg.Get("alpha"); g != g1 || g1 == g2 {
+		t.Fatal("Get does not work as expected")
+	}
+	if g1.Next()>>60 != 1 {
+		t.Fatal("the options are not applied to the generators")
+	}
+	if err := reg.RenewAll(); err != nil {
+		t.Fatal(err)
+	}
+	if h28 != 4 {
+		t.Fatalf("RenewAll does not work as expected. h28: %d", h28)
+	}
+	if err := reg.CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.Get("alpha"); err == nil {
+		t.Fatal("Get should fail after CloseAll is called")
+	}
+}
+
+func TestWithJSSafe(t *testing.T) {
+	var h28 uint64 = 0x1FFFFE
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+
+	g := NewWUID("default", sl, WithJSSafe())
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n := g.Next(); n != 0x1FFFFF<<32+1 || n >= 1<<53 {
+		t.Fatalf("WithJSSafe does not work as expected: %x", n)
+	}
+	if err := g.LoadH28WithCallback(cb); err == nil {
+		t.Fatal("LoadH28WithCallback should fail when the h28 exceeds 21 bits")
+	}
+}
+
+func TestWUID128(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if _, err := NewWUID128(g); err == nil {
+		t.Fatal("NewWUID128 should fail before g loads its high 28 bits")
+	}
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	g128, err := NewWUID128(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := g128.Next()
+	if id.Hi != 42<<36+1 || id.Lo != 1 || ID128FromBytes(id.Bytes()) != id {
+		t.Fatalf("WUID128 does not work as expected: %s", id)
+	}
+}
+
+func TestWithSignedSafe(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 0x7FFFFFF, nil, nil
+	}
+	g := NewWUID("default", sl, WithSignedSafe())
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n := g.Next(); int64(n) < 0 {
+		t.Fatalf("WithSignedSafe does not work as expected: %x", n)
+	}
+}
+
+func TestWithStep(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithStep(2))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n1, n2 := g.Next(), g.Next(); n2-n1 != 2 {
+		t.Fatalf("WithStep does not work as expected: %x, %x", n1, n2)
+	}
+}
+
+func TestWithSkipValues(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithSkipValues(42<<36+1), WithSkipAllOnes(1))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n1, n2 := g.Next(), g.Next(); n1 != 42<<36+2 || n2 != 42<<36+4 {
+		t.Fatalf("the skip options do not work as expected: %x, %x", n1, n2)
+	}
+}
+
+func TestWUID_Peek(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if p := g.Peek(); g.Next() != p || g.LastIssued() != p {
+		t.Fatalf("Peek and LastIssued do not work as expected: %x", p)
+	}
+}
+
+func TestWUID_Stats(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	g.Next()
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := g.Stats(); stats.H28 != 2 || stats.Consumed != 0 || stats.Renews != 1 {
+		t.Fatalf("Stats does not work as expected: %+v", stats)
+	}
+}
+
+func TestWUID_Close(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RenewNow(); err == nil {
+		t.Fatal("RenewNow should fail after Close is called")
+	}
+	defer func() {
+		_ = recover()
+	}()
+	g.Next()
+	t.Fatal("Next should panic after Close is called")
+}
+
+func TestWUID_Restore(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	g1 := NewWUID("default", sl)
+	if err := g1.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	n1 := g1.Next()
+	_ = g1.Close()
+
+	g2 := NewWUID("default", sl)
+	err := g2.Restore(g1.Snapshot(), func() error {
+		return g2.LoadH28WithCallback(cb)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n2 := g2.Next(); n2 != n1+1 {
+		t.Fatalf("Restore does not work as expected: %x, %x", n1, n2)
+	}
+	if err := g2.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if g2.Next()>>36 != 2 {
+		t.Fatal("the renew of a restored WUID does not work as expected")
+	}
+}
+
+func TestTyped(t *testing.T) {
+	type OrderID uint64
+	type UserID int64
+
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithSignedSafe())
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	orders := NewTyped[OrderID](g)
+	users := NewTyped[UserID](g)
+	if id := orders.Next(); id != OrderID(42<<36+1) {
+		t.Fatalf("Typed does not work as expected: %x", id)
+	}
+	if id := users.Next(); id != UserID(42<<36+2) {
+		t.Fatalf("Typed does not work as expected: %x", id)
+	}
+	ids := orders.NextN(3)
+	if len(ids) != 3 || ids[0] != OrderID(42<<36+3) || ids[2] != OrderID(42<<36+5) {
+		t.Fatalf("Typed does not work as expected: %x", ids)
+	}
+	if orders.NextN(0) != nil {
+		t.Fatal("NextN should return nil when n <= 0")
+	}
+}
+
+func TestWUID_NextWithSection(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithSectionWidth(8))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n := g.NextWithSection(200); n>>56 != 200 || n&0xFFFFFFFFF != 1 {
+		t.Fatalf("NextWithSection does not work as expected: %x", n)
+	}
+}
+
+func TestWUID_NextE(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if _, err := g.NextE(); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("NextE should return ErrNotLoaded: %v", err)
+	}
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := g.NextE(); err != nil || n != 42<<36+1 {
+		t.Fatalf("NextE does not work as expected: %x, %v", n, err)
+	}
+	_ = g.Close()
+	if _, err := g.NextE(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("NextE should return ErrClosed: %v", err)
+	}
+}
+
+func TestWUID_LoadH28WithCallbackCtx(t *testing.T) {
+	var h28 uint64
+	var hasDeadline bool
+	cb := func(ctx context.Context) (uint64, func(), error) {
+		_, hasDeadline = ctx.Deadline()
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallbackCtx(ctx, cb); err != nil {
+		t.Fatal(err)
+	}
+	if !hasDeadline {
+		t.Fatal("the context passed to cb should have a deadline")
+	}
+	if err := g.RenewNow(); err != nil || g.Next()>>36 != 2 {
+		t.Fatalf("the renew does not work as expected: %v", err)
+	}
+	cancel()
+	if err := g.RenewNow(); err == nil {
+		t.Fatal("the renew should fail after ctx is canceled")
+	}
+}
+
+func TestWUID_LoadH28WithCallbackCtx_RenewTimeout(t *testing.T) {
+	var h28 uint64
+	var cancelled int32
+	cb := func(ctx context.Context) (uint64, func(), error) {
+		if atomic.AddUint64(&h28, 1) == 1 {
+			return 1, nil, nil
+		}
+		<-ctx.Done()
+		atomic.StoreInt32(&cancelled, 1)
+		return 0, nil, ctx.Err()
+	}
+
+	g := NewWUID("default", sl, WithRenewTimeout(time.Millisecond*100))
+	if err := g.LoadH28WithCallbackCtx(context.Background(), cb); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := g.RenewNow(); !errors.Is(err, ErrRenewTimeout) {
+		t.Fatalf("RenewNow should return ErrRenewTimeout: %v", err)
+	}
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Fatal("the context passed to cb should be canceled")
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("the renew timeout should replace the 5-second timeout")
+	}
+}
+
+func TestWUID_LoadH28WithCallbackCtx_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallbackCtx(context.Background(), nil); err == nil {
+		t.Fatal("LoadH28WithCallbackCtx should fail when cb is nil")
+	}
+	err := g.LoadH28WithCallbackCtx(context.Background(), func(ctx context.Context) (uint64, func(), error) {
+		return 0, nil, errors.New("foo")
+	})
+	if err == nil {
+		t.Fatal("LoadH28WithCallbackCtx should fail when cb returns an error")
+	}
+}
+
+func TestWUID_NextCrockford(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	s := g.NextCrockford()
+	if n, err := DecodeCrockford(s); err != nil || s != "2M0000001" || n != 42<<36+1 {
+		t.Fatalf("NextCrockford does not work as expected: %s, %v", s, err)
+	}
+}
+
+func TestWUID_NextULID(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	s := g.NextULID()
+	if _, n, err := ParseULID(s); err != nil || n != 42<<36+1 {
+		t.Fatalf("NextULID does not work as expected: %s, %v", s, err)
+	}
+	if s := FormatULID(0, 42<<36+1); s != "000000000000000002M0000001" {
+		t.Fatalf("FormatULID does not work as expected: %s", s)
+	}
+}
+
+func TestWUID_NextUUID(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	u := g.NextUUID()
+	if s := u.String(); s != "000002a0-0000-8000-8400-000000000000" {
+		t.Fatalf("NextUUID does not work as expected: %s", s)
+	}
+	v, err := ParseUUID(u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := v.Uint64(); err != nil || n != 42<<36+1 {
+		t.Fatalf("UUID.Uint64 does not work as expected: %x, %v", n, err)
+	}
+}
+
+func TestWithAlphabet(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	const chars = "abcdefghjkmnpqrtwxyz"
+	g := NewWUID("default", sl, WithAlphabet(chars))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := g.NextString()
+	if n, err := a.Decode(s); err != nil || n != 42<<36+1 {
+		t.Fatalf("WithAlphabet does not work as expected: %s, %v", s, err)
+	}
+}
+
+func TestWithObfuscation(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	key := []byte("0123456789abcdef")
+	g := NewWUID("default", sl, WithObfuscation(key))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	v := g.Next()
+	if n, err := Deobfuscate(key, v); err != nil || n != 42<<36+1 {
+		t.Fatalf("WithObfuscation does not work as expected: %x, %v", v, err)
+	}
+	if _, err := Deobfuscate([]byte("short"), v); err == nil {
+		t.Fatal("Deobfuscate should fail")
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithPrefix("ord"))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	s := g.NextPrefixed()
+	if n, err := ParsePrefixed("ord", s); err != nil || n != 42<<36+1 || s != FormatPrefixed("ord", n) {
+		t.Fatalf("WithPrefix does not work as expected: %s, %v", s, err)
+	}
+}
+
+func TestWithRotation(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	g := NewWUID("default", sl, WithRotation(time.Second))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	n1 := g.Next()
+	time.Sleep(time.Millisecond * 1200)
+	n2 := g.Next()
+	if n2>>36 == n1>>36 {
+		t.Fatalf("WithRotation does not work as expected: %x, %x", n1>>36, n2>>36)
+	}
+}
+
+func TestWithRenewThreshold(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	g := NewWUID("default", sl, WithRenewThreshold(0.5))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	n1 := g.Next()
+	g.w.Reset(n1>>36<<36 | 1<<35 - 1)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+	if n2>>36 == n1>>36 {
+		t.Fatalf("WithRenewThreshold does not work as expected: %x, %x", n1>>36, n2>>36)
+	}
+}
+
+func TestWithOnRenew(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	ch := make(chan [2]uint64, 1)
+	g := NewWUID("default", sl, WithOnRenew(func(oldH28, newH28 uint64) {
+		ch <- [2]uint64{oldH28, newH28}
+	}))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-ch; r != [2]uint64{1, 2} {
+		t.Fatalf("WithOnRenew does not work as expected: %v", r)
+	}
+}
+
+func TestWithRenewTimeout(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		if atomic.AddUint64(&h28, 1) > 1 {
+			time.Sleep(time.Second)
+		}
+		return atomic.LoadUint64(&h28), nil, nil
+	}
+	g := NewWUID("default", sl, WithRenewTimeout(time.Millisecond*50))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.RenewNow(); !errors.Is(err, ErrRenewTimeout) {
+		t.Fatalf("RenewNow should return ErrRenewTimeout: %v", err)
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		if atomic.AddUint64(&h28, 1) > 1 {
+			return 0, nil, errors.New("bomb")
+		}
+		return 1, nil, nil
+	}
+	g := NewWUID("default", sl, WithCircuitBreaker(1, time.Minute))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.RenewNow(); err == nil || errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("RenewNow should call the callback: %v", err)
+	}
+	if err := g.RenewNow(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("RenewNow should return ErrBreakerOpen: %v", err)
+	}
+	if !g.Stats().BreakerOpen {
+		t.Fatal("the circuit breaker should be open")
+	}
+}
+
+func TestWithPrefetch(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	g := NewWUID("default", sl, WithPrefetch())
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if g.Stats().H28 != 1 || g.Stats().Spare != 2 {
+		t.Fatalf("the renew should fill the spare block: %+v", g.Stats())
+	}
+	g.w.Reset(1<<36 | (1<<36)*96/100 - 1)
+	if n := g.Next(); n>>36 != 2 {
+		t.Fatalf("Next should switch to the spare block: %x", n)
+	}
+}
+
+func TestWithUsageReport(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 7, nil, nil
+	}
+	var u Usage
+	g := NewWUID("default", sl, WithUsageReport(func(x Usage) error {
+		u = x
+		return nil
+	}))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	g.NextN(5)
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if u.Tag != "default" || u.H28 != 7 || u.Consumed != 5 {
+		t.Fatalf("WithUsageReport does not work as expected: %+v", u)
+	}
+}
+
+func TestWithExhaustionPolicy(t *testing.T) {
+	var h28 uint64
+	cb := func() (uint64, func(), error) {
+		return atomic.AddUint64(&h28, 1), nil, nil
+	}
+	g := NewWUID("default", sl, WithExhaustionPolicy(ExhaustionBlock, time.Second*3))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	g.w.Reset(1<<36 | (1<<36)*96/100)
+	if n := g.Next(); n>>36 != 2 {
+		t.Fatalf("ExhaustionBlock should wait for the renew: %x", n)
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g1 := NewWUID("default", sl, WithRegion(1, 2))
+	g2 := NewWUID("default", sl, WithRegion(2, 2))
+	for _, g := range []*WUID{g1, g2} {
+		if err := g.LoadH28WithCallback(cb); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n1, n2 := g1.Next(), g2.Next()
+	if n1 == n2 || n1>>62 != 1 || n2>>62 != 2 {
+		t.Fatalf("WithRegion does not work as expected: %x, %x", n1, n2)
+	}
+}
+
+func TestWithSnowflake(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithSnowflake(time.Time{}))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	n1, n2 := g.Next(), g.Next()
+	if n2 <= n1 {
+		t.Fatalf("the numbers are not sorted: %x, %x", n1, n2)
+	}
+	ts, worker, _ := DecomposeSnowflake(n2, SnowflakeEpoch)
+	if worker != 42 || time.Since(ts) > time.Minute {
+		t.Fatalf("WithSnowflake does not work as expected: %s, %d", ts, worker)
+	}
+}
+
+func TestWithTimePrefix(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithTimePrefix(16, time.Hour))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	bucket := uint64(time.Since(TimePrefixEpoch) / time.Hour)
+	n := g.Next()
+	if n>>48 != bucket && n>>48 != bucket+1 || n>>36&0xFFF != 42 {
+		t.Fatalf("WithTimePrefix does not work as expected: %x", n)
+	}
+}
+
+func TestWithCheckDigit(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithCheckDigit(), WithPrefix("ord"))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := DecodeBase62Check(g.NextString()); err != nil || n != 42<<36+1 {
+		t.Fatalf("WithCheckDigit does not work as expected: %d, %v", n, err)
+	}
+	s := g.NextPrefixed()
+	if n, err := ParsePrefixedCheck("ord", s); err != nil || n != 42<<36+2 || s != FormatPrefixedCheck("ord", n) {
+		t.Fatalf("WithCheckDigit does not work as expected: %s, %v", s, err)
+	}
+}
+
+func TestWUID_IDRangeForInterval(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithSnowflake(time.Time{}))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	from := time.Now()
+	n := g.Next()
+	lo, hi, err := g.IDRangeForInterval(from, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, err := g.Timestamp(n); err != nil || n < lo || n > hi || time.Since(ts) > time.Minute {
+		t.Fatalf("IDRangeForInterval or Timestamp does not work as expected: %x, %x, %x, %v", lo, n, hi, err)
+	}
+}
+
+func TestBoundsForH28(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithSection(3))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	lo, hi, err := BoundsForH28(42, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := g.Next(); n < lo || n > hi {
+		t.Fatalf("BoundsForH28 does not work as expected: %x, %x, %x", lo, n, hi)
+	}
+}
+
+func TestWithLayoutVersion(t *testing.T) {
+	cb := func() (uint64, func(), error) {
+		return 42, nil, nil
+	}
+	g := NewWUID("default", sl, WithLayoutVersion(1))
+	if err := g.LoadH28WithCallback(cb); err != nil {
+		t.Fatal(err)
+	}
+	if n := g.Next(); LayoutVersion(n) != 1 || n&(1<<62-1) != 42<<36+1 {
+		t.Fatalf("WithLayoutVersion does not work as expected: %x", n)
+	}
+}
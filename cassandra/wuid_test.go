@@ -0,0 +1,135 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/gocql/gocql"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getCassandraConfig() ([]string, string, string, string) {
+	return []string{"127.0.0.1"}, "test", "wuid", "default"
+}
+
+func connect(hosts []string, keyspace string) (*gocql.Session, error) {
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Keyspace = keyspace
+	cluster.Timeout = time.Second * 3
+	return cluster.CreateSession()
+}
+
+func TestWUID_LoadH28FromCassandra(t *testing.T) {
+	hosts, keyspace, table, tag := getCassandraConfig()
+	session, err := connect(hosts, keyspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	err = session.Query(fmt.Sprintf("DELETE FROM %s WHERE tag = ?", table), tag).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromCassandra(session, table, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromCassandra_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromCassandra(nil, "wuid", "default") == nil {
+		t.Fatal("session is not properly checked")
+	}
+	if g.LoadH28FromCassandra(&gocql.Session{}, "", "default") == nil {
+		t.Fatal("table is not properly checked")
+	}
+	if g.LoadH28FromCassandra(&gocql.Session{}, "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	hosts, keyspace, table, tag := getCassandraConfig()
+	session, err := connect(hosts, keyspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromCassandra(session, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	hosts, keyspace, table, tag := getCassandraConfig()
+	session, err := connect(hosts, keyspace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromCassandra(session, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var session *gocql.Session
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromCassandra(session, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+
+[[ "$TRACE" ]] && set -x
+pushd `dirname "$0"` > /dev/null
+trap __EXIT EXIT
+
+colorful=false
+tput setaf 7 > /dev/null 2>&1
+if [[ $? -eq 0 ]]; then
+    colorful=true
+fi
+
+function __EXIT() {
+    popd > /dev/null
+}
+
+docker run -d --name wuid-cockroach -p 26257:26257 cockroachdb/cockroach:latest-v23.2 start-single-node --insecure
+[[ $? -ne 0 ]] && exit 1
+
+for ((i=0;i<1000;i++)); do
+	echo "Trying to create the wuid table [$((i+1))]..."
+	docker exec -i wuid-cockroach ./cockroach sql --insecure < db.sql > /dev/null
+	[[ $? -eq 0 ]] && break
+	sleep 1
+done
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS wuid (
+    h bigint NOT NULL,
+    x smallint NOT NULL DEFAULT 0,
+    PRIMARY KEY (x)
+);
@@ -0,0 +1,203 @@
+package wuid
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/lib/pq"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func init() {
+	dsn, table := getCockroachConfig()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		fmt.Println("cockroach connection error: ", err)
+		return
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(fmt.Sprintf("SELECT 1 FROM %s LIMIT 1", table))
+	if err != nil {
+		format := "Table '%s' doesn't exist. You can create it with github.com/edwingeng/wuid/cockroach/db.sql"
+		panic(fmt.Sprintf(format, table))
+	}
+}
+
+func getCockroachConfig() (string, string) {
+	return "postgres://root@127.0.0.1:26257/defaultdb?sslmode=disable", "wuid"
+}
+
+func TestWUID_LoadH28FromCockroach(t *testing.T) {
+	dsn, table := getCockroachConfig()
+	newDB := func() (*sql.DB, bool, error) {
+		db, err := sql.Open("postgres", dsn)
+		return db, true, err
+	}
+
+	var nextValue uint64
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromCockroach(newDB, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			nextValue = atomic.LoadUint64(&g.w.N)
+		} else {
+			nextValue = ((nextValue >> 36) + 1) << 36
+		}
+		if atomic.LoadUint64(&g.w.N) != nextValue {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), nextValue, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromCockroach_Concurrent(t *testing.T) {
+	dsn, table := getCockroachConfig()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	newDB := func() (*sql.DB, bool, error) {
+		return db, false, nil
+	}
+
+	const total = 20
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	seen := make(map[uint64]bool)
+	wg.Add(total)
+	for i := 0; i < total; i++ {
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromCockroach(newDB, table); err != nil {
+				t.Error(err)
+				return
+			}
+			m.Lock()
+			defer m.Unlock()
+			h28 := atomic.LoadUint64(&g.w.N) >> 36
+			if seen[h28] {
+				t.Errorf("duplicated h28: %d", h28)
+			}
+			seen[h28] = true
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWUID_LoadH28FromCockroach_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromCockroach(nil, "") == nil {
+		t.Fatal("table is not properly checked")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	if !isRetryable(&pq.Error{Code: "40001"}) {
+		t.Fatal("40001 should be retryable")
+	}
+	if !isRetryable(fmt.Errorf("wrapped: %w", &pq.Error{Code: "40001"})) {
+		t.Fatal("a wrapped 40001 should be retryable")
+	}
+	if isRetryable(&pq.Error{Code: "23505"}) {
+		t.Fatal("23505 should not be retryable")
+	}
+	if isRetryable(errors.New("foo")) {
+		t.Fatal("a generic error should not be retryable")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	dsn, table := getCockroachConfig()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDB := func() (*sql.DB, bool, error) {
+		return db, false, nil
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromCockroach(newDB, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	dsn, table := getCockroachConfig()
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDB := func() (*sql.DB, bool, error) {
+		return db, false, nil
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromCockroach(newDB, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	newDB := func() (*sql.DB, bool, error) {
+		var db *sql.DB
+		// ...
+		return db, true, nil
+	}
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromCockroach(newDB, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,230 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/hashicorp/consul/api"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getConsulConfig() (string, string) {
+	return "127.0.0.1:8500", "wuid"
+}
+
+func connect(addr string) (*api.Client, error) {
+	cfg := api.DefaultConfig()
+	cfg.Address = addr
+	return api.NewClient(cfg)
+}
+
+func TestWUID_LoadH28FromConsul(t *testing.T) {
+	addr, key := getConsulConfig()
+	client, err := connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.KV().Delete(key, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err = g.LoadH28FromConsul(client, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromConsul_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromConsul(nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromConsul(&api.Client{}, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromConsulWithLease(t *testing.T) {
+	addr, key := getConsulConfig()
+	client, err := connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.KV().DeleteTree(key, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	defer g.Close()
+	for i := 0; i < 10; i++ {
+		err = g.LoadH28FromConsulWithLease(client, key, time.Second*10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+	}
+
+	pair, _, err := client.KV().Get(LeaseKey(key, 10), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair == nil || pair.Session == "" {
+		t.Fatal("the h28 in use should be recorded with a session")
+	}
+	done, _, err := client.KV().Keys(key+"/done/", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 9 {
+		t.Fatalf("there should be 9 done records, while there are %d", len(done))
+	}
+	abandoned, err := AbandonedH28s(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 0 {
+		t.Fatalf("no h28 should be abandoned: %v", abandoned)
+	}
+}
+
+func TestAbandonedH28s(t *testing.T) {
+	addr, key := getConsulConfig()
+	client, err := connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.KV().DeleteTree(key, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	if err = g.LoadH28FromConsulWithLease(client, key, time.Second*10); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a dead process
+	if _, err = client.Session().Destroy(g.lease.session, nil); err != nil {
+		t.Fatal(err)
+	}
+	abandoned, err := AbandonedH28s(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 1 || abandoned[0] != 1 {
+		t.Fatalf("h28 1 should be abandoned: %v", abandoned)
+	}
+}
+
+func TestWUID_LoadH28FromConsulWithLease_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromConsulWithLease(nil, "wuid", time.Second*10) == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromConsulWithLease(&api.Client{}, "", time.Second*10) == nil {
+		t.Fatal("key is not properly checked")
+	}
+	if g.LoadH28FromConsulWithLease(&api.Client{}, "wuid", time.Second) == nil {
+		t.Fatal("ttl is not properly checked")
+	}
+	g2 := NewWUID("default", sl, WithPrefetch())
+	if g2.LoadH28FromConsulWithLease(&api.Client{}, "wuid", time.Second*10) == nil {
+		t.Fatal("WithPrefetch is not properly checked")
+	}
+	if _, err := AbandonedH28s(nil, "wuid"); err == nil {
+		t.Fatal("client is not properly checked")
+	}
+}
+
+func TestLeaseKey(t *testing.T) {
+	if LeaseKey("wuid", 42) != "wuid/lease/42" {
+		t.Fatal("LeaseKey does not work as expected")
+	}
+	if DoneKey("wuid", 42) != "wuid/done/42" {
+		t.Fatal("DoneKey does not work as expected")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	addr, key := getConsulConfig()
+	client, err := connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromConsul(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	addr, key := getConsulConfig()
+	client, err := connect(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromConsul(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *api.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromConsul(client, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,165 @@
+package wuid
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+// The well-known key of the CosmosDB emulator.
+const emulatorKey = "C2y6yDjf5/R+ob0N8A7Cgv30VRDJIWEHLM+4QDU5DE2nQ9nDuVTqobD4b8mGGyPMbIZnqyMsEcaGQy67XIw/Jw=="
+
+func getCosmosConfig() (string, string, string, string) {
+	return "https://127.0.0.1:8081/", "test", "wuid", "default"
+}
+
+func connect(endpoint, database, container string) (*azcosmos.ContainerClient, error) {
+	cred, err := azcosmos.NewKeyCredential(emulatorKey)
+	if err != nil {
+		return nil, err
+	}
+	// The emulator uses a self-signed certificate.
+	transport := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	client, err := azcosmos.NewClientWithKey(endpoint, cred, &azcosmos.ClientOptions{
+		ClientOptions: azcore.ClientOptions{Transport: transport},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	_, err = client.CreateDatabase(ctx, azcosmos.DatabaseProperties{ID: database}, nil)
+	if err != nil && !hasStatusCode(err, http.StatusConflict) {
+		return nil, err
+	}
+	db, err := client.NewDatabase(database)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.CreateContainer(ctx, azcosmos.ContainerProperties{
+		ID: container,
+		PartitionKeyDefinition: azcosmos.PartitionKeyDefinition{
+			Paths: []string{"/id"},
+		},
+	}, nil)
+	if err != nil && !hasStatusCode(err, http.StatusConflict) {
+		return nil, err
+	}
+	return db.NewContainer(container)
+}
+
+func TestWUID_LoadH28FromCosmos(t *testing.T) {
+	endpoint, database, name, tag := getCosmosConfig()
+	container, err := connect(endpoint, database, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = container.DeleteItem(context.Background(), azcosmos.NewPartitionKeyString(tag), tag, nil)
+	if err != nil && !hasStatusCode(err, http.StatusNotFound) {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromCosmos(container, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromCosmos_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromCosmos(nil, "default") == nil {
+		t.Fatal("container is not properly checked")
+	}
+	if g.LoadH28FromCosmos(&azcosmos.ContainerClient{}, "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	endpoint, database, name, tag := getCosmosConfig()
+	container, err := connect(endpoint, database, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromCosmos(container, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	endpoint, database, name, tag := getCosmosConfig()
+	container, err := connect(endpoint, database, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromCosmos(container, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var container *azcosmos.ContainerClient
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromCosmos(container, "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+
+[[ "$TRACE" ]] && set -x
+pushd `dirname "$0"` > /dev/null
+trap __EXIT EXIT
+
+colorful=false
+tput setaf 7 > /dev/null 2>&1
+if [[ $? -eq 0 ]]; then
+    colorful=true
+fi
+
+function __EXIT() {
+    popd > /dev/null
+}
+
+docker run -d --name wuid-couchbase -p 8091-8097:8091-8097 -p 11210:11210 couchbase/server:community
+sleep 15
+
+docker exec wuid-couchbase couchbase-cli cluster-init -c 127.0.0.1 \
+    --cluster-username Administrator --cluster-password password \
+    --services data --cluster-ramsize 256
+docker exec wuid-couchbase couchbase-cli bucket-create -c 127.0.0.1 \
+    -u Administrator -p password \
+    --bucket test --bucket-type couchbase --bucket-ramsize 128 --bucket-replica 0
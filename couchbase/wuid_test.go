@@ -0,0 +1,132 @@
+package wuid
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getCouchbaseConfig() (string, string, string, string, string) {
+	return "couchbase://127.0.0.1", "Administrator", "password", "test", "wuid"
+}
+
+func connect(t *testing.T) (*gocb.Cluster, *gocb.Collection) {
+	addr, user, pass, bucket, _ := getCouchbaseConfig()
+	cluster, err := gocb.Connect(addr, gocb.ClusterOptions{
+		Authenticator: gocb.PasswordAuthenticator{Username: user, Password: pass},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := cluster.Bucket(bucket)
+	if err = b.WaitUntilReady(time.Second*5, nil); err != nil {
+		t.Fatal(err)
+	}
+	return cluster, b.DefaultCollection()
+}
+
+func TestWUID_LoadH28FromCouchbase(t *testing.T) {
+	_, _, _, _, key := getCouchbaseConfig()
+	cluster, collection := connect(t)
+	defer cluster.Close(nil)
+	_, err := collection.Remove(key, nil)
+	if err != nil && !errors.Is(err, gocb.ErrDocumentNotFound) {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromCouchbase(collection, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromCouchbase_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromCouchbase(nil, "wuid") == nil {
+		t.Fatal("collection is not properly checked")
+	}
+	if g.LoadH28FromCouchbase(&gocb.Collection{}, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, _, _, _, key := getCouchbaseConfig()
+	cluster, collection := connect(t)
+	defer cluster.Close(nil)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromCouchbase(collection, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, _, _, _, key := getCouchbaseConfig()
+	cluster, collection := connect(t)
+	defer cluster.Close(nil)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromCouchbase(collection, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var collection *gocb.Collection
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromCouchbase(collection, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,161 @@
+package wuid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getDynamoDBConfig() (string, string, string) {
+	return "http://127.0.0.1:8000", "wuid", "default"
+}
+
+func connect(endpoint, table string) (*dynamodb.Client, error) {
+	client := dynamodb.New(dynamodb.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		Credentials:  credentials.NewStaticCredentialsProvider("local", "local", ""),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("tag"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("tag"), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	var inUse *types.ResourceInUseException
+	if err != nil && !errors.As(err, &inUse) {
+		return nil, err
+	}
+	return client, nil
+}
+
+func TestWUID_LoadH28FromDynamoDB(t *testing.T) {
+	endpoint, table, tag := getDynamoDBConfig()
+	client, err := connect(endpoint, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.DeleteItem(context.Background(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(table),
+		Key: map[string]types.AttributeValue{
+			"tag": &types.AttributeValueMemberS{Value: tag},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err = g.LoadH28FromDynamoDB(client, table, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromDynamoDB_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromDynamoDB(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromDynamoDB(&dynamodb.Client{}, "", "default") == nil {
+		t.Fatal("table is not properly checked")
+	}
+	if g.LoadH28FromDynamoDB(&dynamodb.Client{}, "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	endpoint, table, tag := getDynamoDBConfig()
+	client, err := connect(endpoint, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromDynamoDB(client, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	endpoint, table, tag := getDynamoDBConfig()
+	client, err := connect(endpoint, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromDynamoDB(client, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *dynamodb.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromDynamoDB(client, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,163 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getElasticConfig() (string, string, string) {
+	return "http://127.0.0.1:9200", "wuid", "default"
+}
+
+func connect(t *testing.T) *elasticsearch.Client {
+	addr, _, _ := getElasticConfig()
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{addr}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func reset(t *testing.T, client *elasticsearch.Client) {
+	_, index, docID := getElasticConfig()
+	resp, err := client.Delete(index, docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestWUID_LoadH28FromElastic(t *testing.T) {
+	_, index, docID := getElasticConfig()
+	client := connect(t)
+	reset(t, client)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromElastic(client, index, docID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromElastic_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromElastic(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromElastic(&elasticsearch.Client{}, "", "default") == nil {
+		t.Fatal("index is not properly checked")
+	}
+	if g.LoadH28FromElastic(&elasticsearch.Client{}, "wuid", "") == nil {
+		t.Fatal("docID is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromElastic_Concurrent(t *testing.T) {
+	_, index, docID := getElasticConfig()
+	client := connect(t)
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make(chan uint64, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromElastic(client, index, docID); err != nil {
+				t.Error(err)
+				return
+			}
+			results <- atomic.LoadUint64(&g.w.N) >> 36
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[uint64]bool)
+	for h28 := range results {
+		if seen[h28] {
+			t.Fatalf("h28 %d is allocated more than once", h28)
+		}
+		seen[h28] = true
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, index, docID := getElasticConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromElastic(client, index, docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, index, docID := getElasticConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromElastic(client, index, docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *elasticsearch.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromElastic(client, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,284 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getEtcdConfig() ([]string, string) {
+	return []string{"127.0.0.1:2379"}, "wuid"
+}
+
+func connect(endpoints []string) (*clientv3.Client, error) {
+	return clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: time.Second * 3,
+	})
+}
+
+func TestWUID_LoadH28FromEtcd(t *testing.T) {
+	endpoints, key := getEtcdConfig()
+	client, err := connect(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if _, err = client.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err = g.LoadH28FromEtcd(client, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromEtcd_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromEtcd(nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromEtcd(&clientv3.Client{}, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromEtcdWithLease(t *testing.T) {
+	endpoints, key := getEtcdConfig()
+	client, err := connect(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if _, err = client.Delete(ctx, key, clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 10; i++ {
+		err = g.LoadH28FromEtcdWithLease(client, key, time.Second*10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+	}
+
+	resp, err := client.Get(ctx, LeaseKey(key, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 || resp.Kvs[0].Lease == 0 {
+		t.Fatal("the h28 in use should be recorded with a lease")
+	}
+	resp, err = client.Get(ctx, key+"/done/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != 9 {
+		t.Fatalf("there should be 9 done records, while there are %d", resp.Count)
+	}
+	abandoned, err := AbandonedH28s(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 0 {
+		t.Fatalf("no h28 should be abandoned: %v", abandoned)
+	}
+}
+
+func TestWUID_Close_Lease(t *testing.T) {
+	endpoints, key := getEtcdConfig()
+	client, err := connect(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if _, err = client.Delete(ctx, key, clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	if err = g.LoadH28FromEtcdWithLease(client, key, time.Second*10); err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(ctx, DoneKey(key, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Fatal("the h28 in use should be retired when the WUID is closed")
+	}
+}
+
+func TestAbandonedH28s(t *testing.T) {
+	endpoints, key := getEtcdConfig()
+	client, err := connect(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	if _, err = client.Delete(ctx, key, clientv3.WithPrefix()); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	if err = g.LoadH28FromEtcdWithLease(client, key, time.Second*10); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a dead process
+	g.lease.cancel()
+	if _, err = client.Revoke(ctx, g.lease.id); err != nil {
+		t.Fatal(err)
+	}
+	abandoned, err := AbandonedH28s(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 1 || abandoned[0] != 1 {
+		t.Fatalf("h28 1 should be abandoned: %v", abandoned)
+	}
+}
+
+func TestWUID_LoadH28FromEtcdWithLease_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromEtcdWithLease(nil, "wuid", time.Second*10) == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromEtcdWithLease(&clientv3.Client{}, "", time.Second*10) == nil {
+		t.Fatal("key is not properly checked")
+	}
+	if g.LoadH28FromEtcdWithLease(&clientv3.Client{}, "wuid", time.Millisecond) == nil {
+		t.Fatal("ttl is not properly checked")
+	}
+	g2 := NewWUID("default", sl, WithPrefetch())
+	if g2.LoadH28FromEtcdWithLease(&clientv3.Client{}, "wuid", time.Second*10) == nil {
+		t.Fatal("WithPrefetch is not properly checked")
+	}
+	if _, err := AbandonedH28s(nil, "wuid"); err == nil {
+		t.Fatal("client is not properly checked")
+	}
+}
+
+func TestLeaseKey(t *testing.T) {
+	if LeaseKey("wuid", 42) != "wuid/lease/42" {
+		t.Fatal("LeaseKey does not work as expected")
+	}
+	if DoneKey("wuid", 42) != "wuid/done/42" {
+		t.Fatal("DoneKey does not work as expected")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	endpoints, key := getEtcdConfig()
+	client, err := connect(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromEtcd(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	endpoints, key := getEtcdConfig()
+	client, err := connect(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromEtcd(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *clientv3.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromEtcd(client, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
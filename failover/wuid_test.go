@@ -0,0 +1,180 @@
+package wuid
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct {
+	mu    sync.Mutex
+	warns []string
+}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.warns = append(this.warns, fmt.Sprint(args...))
+}
+
+func (this *simpleLogger) Warns() []string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return append([]string(nil), this.warns...)
+}
+
+func counterSource(name string, start uint64) Source {
+	h28 := start
+	return Source{Name: name, Load: func() (uint64, error) {
+		return atomic.AddUint64(&h28, 1), nil
+	}}
+}
+
+func brokenSource(name string) Source {
+	return Source{Name: name, Load: func() (uint64, error) {
+		return 0, errors.New("connection refused")
+	}}
+}
+
+func TestWUID_LoadH28WithFailover(t *testing.T) {
+	sl := &simpleLogger{}
+	g := NewWUID("default", sl)
+	primary, fallback := counterSource("primary", 0), counterSource("fallback", 1<<20)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28WithFailover(primary, fallback)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+	if len(sl.Warns()) != 0 {
+		t.Fatalf("no warning should be logged: %v", sl.Warns())
+	}
+}
+
+func TestWUID_LoadH28WithFailover_Fallback(t *testing.T) {
+	sl := &simpleLogger{}
+	g := NewWUID("default", sl)
+	err := g.LoadH28WithFailover(brokenSource("redis"), counterSource("mysql", 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint64(&g.w.N)>>36 != 1<<20+1 {
+		t.Fatalf("the h28 should come from the fallback source: %d", atomic.LoadUint64(&g.w.N)>>36)
+	}
+	warns := sl.Warns()
+	if len(warns) != 1 || !strings.Contains(warns[0], "redis") || !strings.Contains(warns[0], "mysql") {
+		t.Fatalf("a warning about the fallback should be logged: %v", warns)
+	}
+}
+
+func TestWUID_LoadH28WithFailover_InvalidH28(t *testing.T) {
+	sl := &simpleLogger{}
+	g := NewWUID("default", sl)
+	invalid := Source{Name: "invalid", Load: func() (uint64, error) {
+		return 0x10000000, nil
+	}}
+	if err := g.LoadH28WithFailover(invalid, counterSource("mysql", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint64(&g.w.N)>>36 != 1 {
+		t.Fatal("an invalid h28 should make it fall back")
+	}
+}
+
+func TestWUID_LoadH28WithFailover_Error(t *testing.T) {
+	g := NewWUID("default", &simpleLogger{})
+	if g.LoadH28WithFailover() == nil {
+		t.Fatal("sources is not properly checked")
+	}
+	if g.LoadH28WithFailover(Source{Name: "nil"}) == nil {
+		t.Fatal("Load is not properly checked")
+	}
+
+	err := g.LoadH28WithFailover(brokenSource("redis"), brokenSource("mysql"))
+	if err == nil {
+		t.Fatal("LoadH28WithFailover should fail when all sources fail")
+	}
+	if !strings.Contains(err.Error(), "redis") || !strings.Contains(err.Error(), "mysql") {
+		t.Fatalf("the error should mention every source: %s", err)
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	var calls int32
+	flaky := Source{Name: "flaky", Load: func() (uint64, error) {
+		if atomic.AddInt32(&calls, 1)%2 == 0 {
+			return 0, errors.New("timeout")
+		}
+		return uint64(atomic.LoadInt32(&calls)), nil
+	}}
+
+	sl := &simpleLogger{}
+	g := NewWUID("default", sl)
+	err := g.LoadH28WithFailover(flaky, counterSource("fallback", 1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+	if n2>>36 != 1<<20+1 || n3>>36 != 3 {
+		t.Fatalf("every renewal should start over from the first source: %x, %x", n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	g := NewWUID("default", &simpleLogger{}, WithSection(15))
+	err := g.LoadH28WithFailover(counterSource("primary", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var loadFromRedis, loadFromMysql func() (uint64, error)
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28WithFailover(
+		Source{Name: "redis", Load: loadFromRedis},
+		Source{Name: "mysql", Load: loadFromMysql},
+	)
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
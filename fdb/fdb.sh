@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+
+[[ "$TRACE" ]] && set -x
+pushd `dirname "$0"` > /dev/null
+trap __EXIT EXIT
+
+colorful=false
+tput setaf 7 > /dev/null 2>&1
+if [[ $? -eq 0 ]]; then
+    colorful=true
+fi
+
+function __EXIT() {
+    popd > /dev/null
+}
+
+docker run -d --name wuid-fdb -p 4500:4500 foundationdb/foundationdb:7.1.61
+[[ $? -ne 0 ]] && exit 1
+
+for ((i=0;i<1000;i++)); do
+	echo "Trying to configure the database [$((i+1))]..."
+	docker exec wuid-fdb fdbcli --exec "configure new single memory" > /dev/null
+	[[ $? -eq 0 ]] && break
+	sleep 1
+done
@@ -0,0 +1,7 @@
+module github.com/edwingeng/wuid/fdb
+
+go 1.21
+
+require github.com/edwingeng/wuid v0.0.0
+
+replace github.com/edwingeng/wuid => ../
@@ -0,0 +1,181 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func TestWUID_LoadH28FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromFile_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+
+	const n = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromFile(path); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			seen[atomic.LoadUint64(&g.w.N)>>36] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if !t.Failed() && len(seen) != n {
+		t.Fatalf("duplicate h28 detected: %d unique values out of %d", len(seen), n)
+	}
+}
+
+func TestWUID_LoadH28FromFile_Corrupted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+	if err := os.WriteFile(path, []byte("oops"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g := NewWUID("default", sl)
+	if g.LoadH28FromFile(path) == nil {
+		t.Fatal("the content of the file is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromFile_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromFile("") == nil {
+		t.Fatal("path is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromFile("/var/lib/myapp/wuid.h28")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
+
+func TestWUID_ReserveRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	n := uint64(1<<36)*3 - 1
+	start, end, err := g.ReserveRange(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 2<<36 || end != start+n-1 {
+		t.Fatalf("ReserveRange does not work as expected: %x, %x", start, end)
+	}
+	if err = g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if h28 := atomic.LoadUint64(&g.w.N) >> 36; h28 != 5 {
+		t.Fatalf("the h28 values reserved by ReserveRange should be skipped. h28: %d", h28)
+	}
+}
+
+func TestWithBlocksPerRenew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.h28")
+	g := NewWUID("default", sl, WithBlocksPerRenew(4))
+	if err := g.LoadH28FromFile(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := g.RenewNow(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if h28 := atomic.LoadUint64(&g.w.N) >> 36; h28 != 5 {
+		t.Fatalf("WithBlocksPerRenew does not work as expected. h28: %d", h28)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "5" {
+		t.Fatalf("the file should be updated once per 4 renews: %s", data)
+	}
+}
@@ -0,0 +1,139 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getFirestoreConfig() (string, string, string, string) {
+	return "127.0.0.1:8080", "test", "wuid", "default"
+}
+
+func connect(emulator, project string) (*firestore.Client, error) {
+	if err := os.Setenv("FIRESTORE_EMULATOR_HOST", emulator); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	return firestore.NewClient(ctx, project)
+}
+
+func TestWUID_LoadH28FromFirestore(t *testing.T) {
+	emulator, project, collection, tag := getFirestoreConfig()
+	client, err := connect(emulator, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	_, err = client.Collection(collection).Doc(tag).Delete(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromFirestore(client, collection, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromFirestore_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromFirestore(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromFirestore(&firestore.Client{}, "", "default") == nil {
+		t.Fatal("collection is not properly checked")
+	}
+	if g.LoadH28FromFirestore(&firestore.Client{}, "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	emulator, project, collection, tag := getFirestoreConfig()
+	client, err := connect(emulator, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromFirestore(client, collection, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	emulator, project, collection, tag := getFirestoreConfig()
+	client, err := connect(emulator, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromFirestore(client, collection, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *firestore.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromFirestore(client, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,195 @@
+package wuid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/edwingeng/wuid/internal"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getGCSConfig() (string, string, string) {
+	return "http://127.0.0.1:4443/storage/v1/", "wuid", "default"
+}
+
+func connect(endpoint, bucket string) (*storage.Client, error) {
+	client, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	err = client.Bucket(bucket).Create(ctx, "wuid", nil)
+	var apiErr *googleapi.Error
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict) {
+		return nil, err
+	}
+	return client, nil
+}
+
+func TestWUID_LoadH28FromGCS(t *testing.T) {
+	endpoint, bucket, key := getGCSConfig()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Bucket(bucket).Object(key).Delete(context.Background())
+	if err != nil && err != storage.ErrObjectNotExist {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromGCS(client, bucket, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromGCS_Concurrent(t *testing.T) {
+	endpoint, bucket, key := getGCSConfig()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromGCS(client, bucket, key); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			seen[atomic.LoadUint64(&g.w.N)>>36] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if !t.Failed() && len(seen) != n {
+		t.Fatalf("duplicate h28 detected: %d unique values out of %d", len(seen), n)
+	}
+}
+
+func TestWUID_LoadH28FromGCS_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromGCS(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromGCS(&storage.Client{}, "", "default") == nil {
+		t.Fatal("bucket is not properly checked")
+	}
+	if g.LoadH28FromGCS(&storage.Client{}, "wuid", "") == nil {
+		t.Fatal("object is not properly checked")
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	if isConflict(nil) {
+		t.Fatal("nil should not be a conflict")
+	}
+	if isConflict(errors.New("412")) {
+		t.Fatal("a plain error should not be a conflict")
+	}
+	err := fmt.Errorf("write: %w", &googleapi.Error{Code: http.StatusPreconditionFailed})
+	if !isConflict(err) {
+		t.Fatal("412 should be a conflict")
+	}
+	if isConflict(&googleapi.Error{Code: http.StatusForbidden}) {
+		t.Fatal("403 should not be a conflict")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	endpoint, bucket, key := getGCSConfig()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromGCS(client, bucket, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	endpoint, bucket, key := getGCSConfig()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromGCS(client, bucket, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *storage.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromGCS(client, "my-bucket", "wuid/default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,137 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/hazelcast/hazelcast-go-client"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getHazelcastConfig() (string, string) {
+	return "127.0.0.1:5701", "wuid"
+}
+
+func connect(t *testing.T) *hazelcast.Client {
+	addr, _ := getHazelcastConfig()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	var cfg hazelcast.Config
+	cfg.Cluster.Network.SetAddresses(addr)
+	client, err := hazelcast.StartNewClientWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Shutdown(context.Background()) })
+	return client
+}
+
+func reset(t *testing.T, client *hazelcast.Client, name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	counter, err := client.CPSubsystem().GetAtomicLong(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = counter.Set(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWUID_LoadH28FromHazelcast(t *testing.T) {
+	_, name := getHazelcastConfig()
+	client := connect(t)
+	reset(t, client, name)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromHazelcast(client, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromHazelcast_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromHazelcast(nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromHazelcast(&hazelcast.Client{}, "") == nil {
+		t.Fatal("name is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, name := getHazelcastConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromHazelcast(client, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, name := getHazelcastConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromHazelcast(client, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *hazelcast.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromHazelcast(client, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,159 @@
+package wuid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+const testToken = "secret"
+
+func newServer(t *testing.T) *httptest.Server {
+	var counter uint64
+	alloc := func(ctx context.Context, tag string) (uint64, error) {
+		if tag != "default" {
+			return 0, errors.New("unknown tag")
+		}
+		return atomic.AddUint64(&counter, 1), nil
+	}
+	srv := httptest.NewTLSServer(NewHandler(testToken, alloc))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWUID_LoadH28FromHTTP(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromHTTP(srv.URL, testToken, WithHTTPClient(srv.Client()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromHTTP_TLSConfig(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl)
+	if g.LoadH28FromHTTP(srv.URL, testToken) == nil {
+		t.Fatal("the certificate of the test server should not be trusted by default")
+	}
+	tr := srv.Client().Transport.(*http.Transport)
+	if err := g.LoadH28FromHTTP(srv.URL, testToken, WithTLSConfig(tr.TLSClientConfig)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWUID_LoadH28FromHTTP_Retry(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			http.Error(w, "try again later", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"h28": 42}`))
+	}))
+	defer srv.Close()
+
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromHTTP(srv.URL, "", WithRetries(1, time.Millisecond)); err == nil {
+		t.Fatal("the number of retries is not respected")
+	}
+	if err := g.LoadH28FromHTTP(srv.URL, "", WithRetries(3, time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint64(&g.w.N)>>36 != 42 {
+		t.Fatalf("the h28 is %d, while it should be 42", atomic.LoadUint64(&g.w.N)>>36)
+	}
+}
+
+func TestWUID_LoadH28FromHTTP_Error(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl)
+	if g.LoadH28FromHTTP("", testToken) == nil {
+		t.Fatal("url is not properly checked")
+	}
+	if g.LoadH28FromHTTP(srv.URL, "wrong", WithHTTPClient(srv.Client())) == nil {
+		t.Fatal("token is not properly checked")
+	}
+	g2 := NewWUID("other", sl)
+	if g2.LoadH28FromHTTP(srv.URL, testToken, WithHTTPClient(srv.Client()), WithRetries(0, 0)) == nil {
+		t.Fatal("the error of alloc is not properly propagated")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromHTTP(srv.URL, testToken, WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromHTTP(srv.URL, testToken, WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromHTTP("https://wuid.internal/h28", "my-token")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,138 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+// newServer starts a fake Ignite REST endpoint which only understands the incr command.
+func newServer(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	counters := make(map[string]int64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/ignite" || q.Get("cmd") != "incr" {
+			_, _ = fmt.Fprint(w, `{"successStatus":1,"error":"unsupported command","response":null}`)
+			return
+		}
+		delta, _ := strconv.ParseInt(q.Get("delta"), 10, 64)
+		mu.Lock()
+		name := q.Get("key")
+		if _, ok := counters[name]; !ok {
+			counters[name], _ = strconv.ParseInt(q.Get("init"), 10, 64)
+		}
+		counters[name] += delta
+		n := counters[name]
+		mu.Unlock()
+		_, _ = fmt.Fprintf(w, `{"successStatus":0,"error":null,"response":%d}`, n)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWUID_LoadH28FromIgnite(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromIgnite(srv.Client(), srv.URL, "wuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromIgnite_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromIgnite(nil, "http://127.0.0.1:8080", "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromIgnite(http.DefaultClient, "", "wuid") == nil {
+		t.Fatal("baseURL is not properly checked")
+	}
+	if g.LoadH28FromIgnite(http.DefaultClient, "http://127.0.0.1:8080", "") == nil {
+		t.Fatal("name is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromIgnite_Failure(t *testing.T) {
+	srv := newServer(t)
+	g := NewWUID("default", sl)
+	if g.LoadH28FromIgnite(srv.Client(), srv.URL+"/nowhere", "wuid") == nil {
+		t.Fatal("the failure of the REST call should be reported")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromIgnite(srv.Client(), srv.URL, "wuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	srv := newServer(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromIgnite(srv.Client(), srv.URL, "wuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromIgnite(http.DefaultClient, "http://127.0.0.1:8080", "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
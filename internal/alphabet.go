@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ambiguousGroups = []string{"0Oo", "1IiLl|"}
+
+// Alphabet is for internal use only.
+type Alphabet struct {
+	chars string
+	index [256]int16
+}
+
+// NewAlphabet is for internal use only.
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if len(chars) < 2 {
+		return nil, errors.New("an alphabet should have at least 2 characters")
+	}
+	a := &Alphabet{chars: chars}
+	for i := range a.index {
+		a.index[i] = -1
+	}
+	for i := 0; i < len(chars); i++ {
+		c := chars[i]
+		if c <= ' ' || c > '~' {
+			return nil, fmt.Errorf("an alphabet should consist of printable ASCII characters: %q", chars)
+		}
+		if a.index[c] >= 0 {
+			return nil, fmt.Errorf("the character %q appears more than once in the alphabet: %s", c, chars)
+		}
+		a.index[c] = int16(i)
+	}
+	for _, group := range ambiguousGroups {
+		var found []byte
+		for i := 0; i < len(group); i++ {
+			if strings.IndexByte(chars, group[i]) >= 0 {
+				found = append(found, group[i])
+			}
+		}
+		if len(found) > 1 {
+			return nil, fmt.Errorf("the characters %q are ambiguous in the alphabet: %s", found, chars)
+		}
+	}
+	return a, nil
+}
+
+// String returns the characters of the alphabet.
+func (a *Alphabet) String() string {
+	return a.chars
+}
+
+// Encode encodes n with the characters of the alphabet.
+func (a *Alphabet) Encode(n uint64) string {
+	if n == 0 {
+		return a.chars[:1]
+	}
+	base := uint64(len(a.chars))
+	var buf [64]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = a.chars[n%base]
+		n /= base
+	}
+	return string(buf[i:])
+}
+
+// Decode decodes a string produced by Encode.
+func (a *Alphabet) Decode(s string) (uint64, error) {
+	if len(s) == 0 {
+		return 0, errors.New("the string cannot be empty")
+	}
+	base := uint64(len(a.chars))
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		d := a.index[s[i]]
+		if d < 0 {
+			return 0, errors.New("the string contains characters out of the alphabet: " + s)
+		}
+		if n > (1<<64-1-uint64(d))/base {
+			return 0, errors.New("the string overflows uint64: " + s)
+		}
+		n = n*base + uint64(d)
+	}
+	return n, nil
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewAlphabet(t *testing.T) {
+	for _, chars := range []string{"", "a", "abca", "ab c", "abc\x7f", "0O", "1l", "abcIi"} {
+		if _, err := NewAlphabet(chars); err == nil {
+			t.Fatalf("NewAlphabet should fail. chars: %q", chars)
+		}
+	}
+	for _, chars := range []string{"01", crockfordAlphabet, "abcdefghjkmnpqrtwxyz"} {
+		if _, err := NewAlphabet(chars); err != nil {
+			t.Fatalf("NewAlphabet should succeed. chars: %q, err: %s", chars, err)
+		}
+	}
+}
+
+func TestAlphabet(t *testing.T) {
+	a, err := NewAlphabet("01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := a.Encode(0); s != "0" {
+		t.Fatalf("Alphabet.Encode does not work as expected: %s", s)
+	}
+	if s := a.Encode(math.MaxUint64); len(s) != 64 {
+		t.Fatalf("Alphabet.Encode does not work as expected: %s", s)
+	}
+
+	a, _ = NewAlphabet("abcdefghjkmnpqrtwxyz")
+	for i := 0; i < 10000; i++ {
+		n := rand.Uint64()
+		v, err := a.Decode(a.Encode(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("Alphabet.Decode does not work as expected. n: %d, v: %d", n, v)
+		}
+	}
+	for _, s := range []string{"", "abc0", "zzzzzzzzzzzzzzzz"} {
+		if _, err := a.Decode(s); err == nil {
+			t.Fatalf("Alphabet.Decode should fail. s: %q", s)
+		}
+	}
+}
+
+func TestWithAlphabet(t *testing.T) {
+	g := NewWUID("default", nil, WithAlphabet("abcdefghjkmnpqrtwxyz"))
+	g.Reset(1 << 36)
+	s := g.NextString()
+	n, err := g.alphabet.Decode(s)
+	if err != nil || n != 1<<36+1 {
+		t.Fatalf("WithAlphabet does not work as expected: %s, %v", s, err)
+	}
+
+	defer func() {
+		_ = recover()
+	}()
+	WithAlphabet("aa")
+	t.Fatal("WithAlphabet should panic when the alphabet is invalid")
+}
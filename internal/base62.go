@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"errors"
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var base62Index = func() (a [256]int8) {
+	for i := range a {
+		a[i] = -1
+	}
+	for i := 0; i < len(base62Alphabet); i++ {
+		a[base62Alphabet[i]] = int8(i)
+	}
+	return
+}()
+
+// EncodeBase62 is for internal use only.
+func EncodeBase62(n uint64) string {
+	if n == 0 {
+		return "0"
+	}
+	var buf [11]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(buf[i:])
+}
+
+// DecodeBase62 is for internal use only.
+func DecodeBase62(s string) (uint64, error) {
+	if len(s) == 0 {
+		return 0, errors.New("the base62 string cannot be empty")
+	}
+	if len(s) > 11 {
+		return 0, errors.New("the base62 string is too long: " + s)
+	}
+	var n uint64
+	for i := 0; i < len(s); i++ {
+		d := base62Index[s[i]]
+		if d < 0 {
+			return 0, errors.New("invalid base62 string: " + s)
+		}
+		if n > (1<<64-1-uint64(d))/62 {
+			return 0, errors.New("the base62 string overflows uint64: " + s)
+		}
+		n = n*62 + uint64(d)
+	}
+	return n, nil
+}
+
+// NextString is for internal use only.
+func (this *WUID) NextString() string {
+	switch {
+	case this.alphabet != nil && this.checkDigit:
+		return this.alphabet.EncodeCheck(this.Next())
+	case this.alphabet != nil:
+		return this.alphabet.Encode(this.Next())
+	case this.checkDigit:
+		return EncodeBase62Check(this.Next())
+	default:
+		return EncodeBase62(this.Next())
+	}
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeBase62(t *testing.T) {
+	cases := map[uint64]string{
+		0:              "0",
+		61:             "z",
+		62:             "10",
+		math.MaxUint64: "LygHa16AHYF",
+	}
+	for n, s := range cases {
+		if v := EncodeBase62(n); v != s {
+			t.Fatalf("EncodeBase62(%d) is %s, while it should be %s", n, v, s)
+		}
+	}
+}
+
+func TestDecodeBase62(t *testing.T) {
+	for i := 0; i < 10000; i++ {
+		n := rand.Uint64()
+		v, err := DecodeBase62(EncodeBase62(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("DecodeBase62 does not work as expected. n: %d, v: %d", n, v)
+		}
+	}
+
+	for _, s := range []string{"", "-1", "a_b", "LygHa16AHYG", "100000000000"} {
+		if _, err := DecodeBase62(s); err == nil {
+			t.Fatalf("DecodeBase62 should fail. s: %q", s)
+		}
+	}
+}
+
+func TestWUID_NextString(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Reset(1 << 36)
+	s := g.NextString()
+	n, err := DecodeBase62(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1<<36+1 {
+		t.Fatalf("NextString does not work as expected: %s", s)
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"errors"
+)
+
+// luhn returns the Luhn mod N check digit of s, where base is N and index maps the characters
+// of s to their digits.
+func luhn(s string, base int, index func(c byte) int) int {
+	factor, sum := 2, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		addend := factor * index(s[i])
+		sum += addend/base + addend%base
+		factor = 3 - factor
+	}
+	return (base - sum%base) % base
+}
+
+// trimCheckDigit verifies the last character of s as the check digit of the rest, and returns
+// the rest.
+func trimCheckDigit(s string, chars string, index func(c byte) int) (string, error) {
+	if len(s) < 2 {
+		return "", errors.New("the string is too short to carry a check digit: " + s)
+	}
+	body := s[:len(s)-1]
+	for i := 0; i < len(body); i++ {
+		if index(body[i]) < 0 {
+			return "", errors.New("the string contains invalid characters: " + s)
+		}
+	}
+	if s[len(s)-1] != chars[luhn(body, len(chars), index)] {
+		return "", errors.New("the check digit does not match: " + s)
+	}
+	return body, nil
+}
+
+func base62Digit(c byte) int {
+	return int(base62Index[c])
+}
+
+// EncodeBase62Check is for internal use only.
+func EncodeBase62Check(n uint64) string {
+	s := EncodeBase62(n)
+	i := luhn(s, len(base62Alphabet), base62Digit)
+	return s + base62Alphabet[i:i+1]
+}
+
+// DecodeBase62Check is for internal use only.
+func DecodeBase62Check(s string) (uint64, error) {
+	body, err := trimCheckDigit(s, base62Alphabet, base62Digit)
+	if err != nil {
+		return 0, err
+	}
+	return DecodeBase62(body)
+}
+
+// EncodeCheck works like Encode, but appends a check digit.
+func (a *Alphabet) EncodeCheck(n uint64) string {
+	s := a.Encode(n)
+	i := luhn(s, len(a.chars), a.digit)
+	return s + a.chars[i:i+1]
+}
+
+// DecodeCheck decodes a string produced by EncodeCheck, and rejects it if the check digit does
+// not match.
+func (a *Alphabet) DecodeCheck(s string) (uint64, error) {
+	body, err := trimCheckDigit(s, a.chars, a.digit)
+	if err != nil {
+		return 0, err
+	}
+	return a.Decode(body)
+}
+
+func (a *Alphabet) digit(c byte) int {
+	return int(a.index[c])
+}
+
+// FormatPrefixedCheck is for internal use only.
+func FormatPrefixedCheck(prefix string, n uint64) string {
+	return prefix + "_" + EncodeBase62Check(n)
+}
+
+// ParsePrefixedCheck is for internal use only.
+func ParsePrefixedCheck(prefix, s string) (uint64, error) {
+	return parsePrefixed(prefix, s, DecodeBase62Check, EncodeBase62Check)
+}
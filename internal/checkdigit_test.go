@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBase62Check(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		n := rand.Uint64()
+		s := EncodeBase62Check(n)
+		v, err := DecodeBase62Check(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("DecodeBase62Check does not work as expected. n: %d, v: %d", n, v)
+		}
+
+		// Every single-character typo must be caught.
+		b := []byte(s)
+		for j := range b {
+			c := b[j]
+			for k := 0; k < len(base62Alphabet); k++ {
+				if base62Alphabet[k] == c {
+					continue
+				}
+				b[j] = base62Alphabet[k]
+				if _, err := DecodeBase62Check(string(b)); err == nil {
+					t.Fatalf("DecodeBase62Check should fail. s: %s, typo: %s", s, b)
+				}
+			}
+			b[j] = c
+		}
+	}
+	for _, s := range []string{"", "A", "A-", "AB"} {
+		if _, err := DecodeBase62Check(s); err == nil {
+			t.Fatalf("DecodeBase62Check should fail. s: %q", s)
+		}
+	}
+}
+
+func TestAlphabet_Check(t *testing.T) {
+	a, _ := NewAlphabet("abcdefghjkmnpqrtwxyz")
+	for i := 0; i < 1000; i++ {
+		n := rand.Uint64()
+		s := a.EncodeCheck(n)
+		v, err := a.DecodeCheck(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("Alphabet.DecodeCheck does not work as expected. n: %d, v: %d", n, v)
+		}
+		// Luhn mod N misses only the transposition of the digits 0 and N-1.
+		d0, d1 := a.digit(s[0]), a.digit(s[1])
+		if d0 != d1 && !(d0+d1 == 19 && d0*d1 == 0) {
+			swapped := string(s[1]) + string(s[0]) + s[2:]
+			if _, err := a.DecodeCheck(swapped); err == nil {
+				t.Fatalf("Alphabet.DecodeCheck should fail. s: %s, swapped: %s", s, swapped)
+			}
+		}
+	}
+}
+
+func TestWithCheckDigit(t *testing.T) {
+	g := NewWUID("default", nil, WithCheckDigit(), WithPrefix("ord"))
+	g.Reset(1 << 36)
+	if n, err := DecodeBase62Check(g.NextString()); err != nil || n != 1<<36+1 {
+		t.Fatalf("WithCheckDigit does not work as expected: %d, %v", n, err)
+	}
+	s := g.NextPrefixed()
+	if n, err := ParsePrefixedCheck("ord", s); err != nil || n != 1<<36+2 {
+		t.Fatalf("WithCheckDigit does not work as expected: %s, %v", s, err)
+	}
+	if _, err := ParsePrefixedCheck("ord", s[:len(s)-1]+"x"); err == nil {
+		t.Fatal("ParsePrefixedCheck should fail")
+	}
+
+	g = NewWUID("default", nil, WithCheckDigit(), WithAlphabet("abcdefghjkmnpqrtwxyz"))
+	g.Reset(1 << 36)
+	if n, err := g.alphabet.DecodeCheck(g.NextString()); err != nil || n != 1<<36+1 {
+		t.Fatalf("WithCheckDigit does not work as expected: %d, %v", n, err)
+	}
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"errors"
+)
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordIndex = func() (a [256]int8) {
+	for i := range a {
+		a[i] = -1
+	}
+	for i := 0; i < len(crockfordAlphabet); i++ {
+		c := crockfordAlphabet[i]
+		a[c] = int8(i)
+		if c >= 'A' && c <= 'Z' {
+			a[c+'a'-'A'] = int8(i)
+		}
+	}
+	for _, c := range "oO" {
+		a[c] = 0
+	}
+	for _, c := range "iIlL" {
+		a[c] = 1
+	}
+	return
+}()
+
+// EncodeCrockford is for internal use only.
+func EncodeCrockford(n uint64) string {
+	if n == 0 {
+		return "0"
+	}
+	var buf [13]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = crockfordAlphabet[n&31]
+		n >>= 5
+	}
+	return string(buf[i:])
+}
+
+// DecodeCrockford is for internal use only.
+func DecodeCrockford(s string) (uint64, error) {
+	var n uint64
+	var digits int
+	for i := 0; i < len(s); i++ {
+		if s[i] == '-' {
+			continue
+		}
+		d := crockfordIndex[s[i]]
+		if d < 0 {
+			return 0, errors.New("invalid base32 string: " + s)
+		}
+		if n>>59 != 0 {
+			return 0, errors.New("the base32 string overflows uint64: " + s)
+		}
+		n = n<<5 | uint64(d)
+		digits++
+	}
+	if digits == 0 {
+		return 0, errors.New("the base32 string cannot be empty")
+	}
+	return n, nil
+}
+
+// NextCrockford is for internal use only.
+func (this *WUID) NextCrockford() string {
+	return EncodeCrockford(this.Next())
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeCrockford(t *testing.T) {
+	cases := map[uint64]string{
+		0:              "0",
+		31:             "Z",
+		32:             "10",
+		math.MaxUint64: "FZZZZZZZZZZZZ",
+	}
+	for n, s := range cases {
+		if v := EncodeCrockford(n); v != s {
+			t.Fatalf("EncodeCrockford(%d) is %s, while it should be %s", n, v, s)
+		}
+	}
+}
+
+func TestDecodeCrockford(t *testing.T) {
+	for i := 0; i < 10000; i++ {
+		n := rand.Uint64()
+		v, err := DecodeCrockford(EncodeCrockford(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("DecodeCrockford does not work as expected. n: %d, v: %d", n, v)
+		}
+	}
+
+	cases := map[string]uint64{
+		"z":       31,
+		"1o":      32,
+		"IL-iL":   0x8421,
+		"abc-def": 0x14B635CF,
+	}
+	for s, n := range cases {
+		if v, err := DecodeCrockford(s); err != nil || v != n {
+			t.Fatalf("DecodeCrockford(%q) is %d, while it should be %d. err: %v", s, v, n, err)
+		}
+	}
+
+	for _, s := range []string{"", "-", "U", "a_b", "G0000000000000", "10000000000000"} {
+		if _, err := DecodeCrockford(s); err == nil {
+			t.Fatalf("DecodeCrockford should fail. s: %q", s)
+		}
+	}
+}
+
+func TestWUID_NextCrockford(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Reset(1 << 36)
+	s := g.NextCrockford()
+	n, err := DecodeCrockford(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1<<36+1 {
+		t.Fatalf("NextCrockford does not work as expected: %s", s)
+	}
+}
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ID is for internal use only.
+type ID uint64
+
+// String returns the decimal form of the ID.
+func (id ID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// Hex returns the hexadecimal form of the ID, e.g. 0x0000010000000001.
+func (id ID) Hex() string {
+	return fmt.Sprintf("%#016x", uint64(id))
+}
+
+// Section returns the highest 4 bits of the ID, which are the section ID if the generator was
+// created with WithSection.
+func (id ID) Section() uint8 {
+	return uint8(id >> 60)
+}
+
+// H28 returns the high 28 bits of the ID, which include the section ID if any.
+func (id ID) H28() uint64 {
+	return uint64(id) >> 36
+}
+
+// MarshalJSON encodes the ID as a JSON number.
+func (id ID) MarshalJSON() ([]byte, error) {
+	return strconv.AppendUint(nil, uint64(id), 10), nil
+}
+
+// UnmarshalJSON decodes the ID from either a JSON number or a JSON string.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	n, err := unmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*id = ID(n)
+	return nil
+}
+
+// MarshalText encodes the ID in the decimal form.
+func (id ID) MarshalText() ([]byte, error) {
+	return strconv.AppendUint(nil, uint64(id), 10), nil
+}
+
+// UnmarshalText decodes the ID from the decimal form.
+func (id *ID) UnmarshalText(text []byte) error {
+	n, err := strconv.ParseUint(string(text), 10, 64)
+	if err != nil {
+		return errors.New("invalid ID: " + string(text))
+	}
+	*id = ID(n)
+	return nil
+}
+
+// MarshalBinary encodes the ID into 8 bytes in big-endian order.
+func (id ID) MarshalBinary() ([]byte, error) {
+	return binary.BigEndian.AppendUint64(nil, uint64(id)), nil
+}
+
+// UnmarshalBinary decodes the ID from 8 bytes in big-endian order.
+func (id *ID) UnmarshalBinary(data []byte) error {
+	if len(data) != 8 {
+		return fmt.Errorf("the binary form of an ID should be 8 bytes long, not %d", len(data))
+	}
+	*id = ID(binary.BigEndian.Uint64(data))
+	return nil
+}
+
+// Value stores the ID as a BIGINT. An ID with the highest bit set is stored as a negative number,
+// which Scan turns back into the same ID.
+func (id ID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
+
+// Scan reads the ID from a BIGINT or a string column.
+func (id *ID) Scan(src interface{}) error {
+	n, err := scan(src)
+	if err != nil {
+		return err
+	}
+	*id = ID(n)
+	return nil
+}
+
+// StringID is for internal use only.
+type StringID uint64
+
+// String returns the decimal form of the ID.
+func (id StringID) String() string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// MarshalJSON encodes the ID as a JSON string, which JavaScript clients can read without losing
+// precision.
+func (id StringID) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 22)
+	b = append(b, '"')
+	b = strconv.AppendUint(b, uint64(id), 10)
+	return append(b, '"'), nil
+}
+
+// UnmarshalJSON decodes the ID from either a JSON string or a JSON number.
+func (id *StringID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	n, err := unmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*id = StringID(n)
+	return nil
+}
+
+// MarshalText encodes the ID in the decimal form.
+func (id StringID) MarshalText() ([]byte, error) {
+	return ID(id).MarshalText()
+}
+
+// UnmarshalText decodes the ID from the decimal form.
+func (id *StringID) UnmarshalText(text []byte) error {
+	return (*ID)(id).UnmarshalText(text)
+}
+
+// MarshalBinary encodes the ID into 8 bytes in big-endian order.
+func (id StringID) MarshalBinary() ([]byte, error) {
+	return ID(id).MarshalBinary()
+}
+
+// UnmarshalBinary decodes the ID from 8 bytes in big-endian order.
+func (id *StringID) UnmarshalBinary(data []byte) error {
+	return (*ID)(id).UnmarshalBinary(data)
+}
+
+// Value stores the ID as a decimal string.
+func (id StringID) Value() (driver.Value, error) {
+	return id.String(), nil
+}
+
+// Scan reads the ID from a string or a BIGINT column.
+func (id *StringID) Scan(src interface{}) error {
+	n, err := scan(src)
+	if err != nil {
+		return err
+	}
+	*id = StringID(n)
+	return nil
+}
+
+func scan(src interface{}) (uint64, error) {
+	switch v := src.(type) {
+	case int64:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case []byte:
+		return parseDecimal(string(v))
+	case string:
+		return parseDecimal(v)
+	case nil:
+		return 0, errors.New("cannot scan NULL into an ID")
+	default:
+		return 0, fmt.Errorf("cannot scan %T into an ID", src)
+	}
+}
+
+func parseDecimal(s string) (uint64, error) {
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return n, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid ID: " + s)
+	}
+	return uint64(n), nil
+}
+
+func unmarshalJSON(data []byte) (uint64, error) {
+	s := string(data)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid ID: " + string(data))
+	}
+	return n, nil
+}
+
+// NextID is for internal use only.
+func (this *WUID) NextID() ID {
+	return ID(this.Next())
+}
@@ -0,0 +1,213 @@
+package internal
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+var (
+	_ driver.Valuer = ID(0)
+	_ sql.Scanner   = (*ID)(nil)
+	_ driver.Valuer = StringID(0)
+	_ sql.Scanner   = (*StringID)(nil)
+
+	_ encoding.TextMarshaler     = ID(0)
+	_ encoding.TextUnmarshaler   = (*ID)(nil)
+	_ encoding.BinaryMarshaler   = ID(0)
+	_ encoding.BinaryUnmarshaler = (*ID)(nil)
+	_ encoding.TextMarshaler     = StringID(0)
+	_ encoding.TextUnmarshaler   = (*StringID)(nil)
+	_ encoding.BinaryMarshaler   = StringID(0)
+	_ encoding.BinaryUnmarshaler = (*StringID)(nil)
+)
+
+func TestID(t *testing.T) {
+	id := ID(0xF00002A000000001)
+	if id.String() != "17293825455320727553" {
+		t.Fatalf("id.String() does not work as expected: %s", id.String())
+	}
+	if id.Hex() != "0xf00002a000000001" {
+		t.Fatalf("id.Hex() does not work as expected: %s", id.Hex())
+	}
+	if id.Section() != 15 {
+		t.Fatalf("id.Section() does not work as expected: %d", id.Section())
+	}
+	if id.H28() != 0xF00002A {
+		t.Fatalf("id.H28() does not work as expected: %x", id.H28())
+	}
+	if ID(1).Hex() != "0x0000000000000001" {
+		t.Fatalf("id.Hex() does not work as expected: %s", ID(1).Hex())
+	}
+}
+
+func TestID_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		ID ID `json:"id"`
+	}{ID: 0xF00002A000000001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"id":17293825455320727553}` {
+		t.Fatalf("MarshalJSON does not work as expected: %s", data)
+	}
+}
+
+func TestID_UnmarshalJSON(t *testing.T) {
+	for _, data := range []string{`17293825455320727553`, `"17293825455320727553"`} {
+		var id ID
+		if err := json.Unmarshal([]byte(data), &id); err != nil {
+			t.Fatal(err)
+		}
+		if id != 0xF00002A000000001 {
+			t.Fatalf("UnmarshalJSON does not work as expected. data: %s, id: %s", data, id.Hex())
+		}
+	}
+	for _, data := range []string{`""`, `"abc"`, `-1`, `1.5`, `"18446744073709551616"`} {
+		var id ID
+		if err := json.Unmarshal([]byte(data), &id); err == nil {
+			t.Fatalf("UnmarshalJSON should fail. data: %s", data)
+		}
+	}
+
+	id := ID(42)
+	if err := json.Unmarshal([]byte(`null`), &id); err != nil || id != 42 {
+		t.Fatal("UnmarshalJSON should leave the ID as is for null")
+	}
+}
+
+func TestStringID_JSON(t *testing.T) {
+	var v struct {
+		ID StringID `json:"id"`
+	}
+	v.ID = 0xF00002A000000001
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"id":"17293825455320727553"}` {
+		t.Fatalf("MarshalJSON does not work as expected: %s", data)
+	}
+
+	for _, data := range []string{`{"id":"17293825455320727553"}`, `{"id":17293825455320727553}`} {
+		v.ID = 0
+		if err := json.Unmarshal([]byte(data), &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.ID != 0xF00002A000000001 {
+			t.Fatalf("UnmarshalJSON does not work as expected. data: %s, id: %s", data, v.ID)
+		}
+	}
+}
+
+func TestID_Value(t *testing.T) {
+	for _, id := range []ID{1, 0x0F00002A00000001, 0xF00002A000000001} {
+		v, err := id.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := v.(int64); !ok {
+			t.Fatalf("the value should be an int64: %T", v)
+		}
+		var id2 ID
+		if err = id2.Scan(v); err != nil {
+			t.Fatal(err)
+		}
+		if id2 != id {
+			t.Fatalf("Scan does not work as expected. id: %s, id2: %s", id.Hex(), id2.Hex())
+		}
+	}
+
+	var id ID
+	for _, src := range []interface{}{"42", []byte("42"), int64(42), uint64(42)} {
+		if err := id.Scan(src); err != nil || id != 42 {
+			t.Fatalf("Scan does not work as expected. src: %v", src)
+		}
+	}
+	for _, src := range []interface{}{nil, "abc", 1.5, true} {
+		if err := id.Scan(src); err == nil {
+			t.Fatalf("Scan should fail. src: %v", src)
+		}
+	}
+}
+
+func TestStringID_Value(t *testing.T) {
+	id := StringID(0xF00002A000000001)
+	v, err := id.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != "17293825455320727553" {
+		t.Fatalf("Value does not work as expected: %v", v)
+	}
+	var id2 StringID
+	if err = id2.Scan([]byte("17293825455320727553")); err != nil {
+		t.Fatal(err)
+	}
+	if id2 != id {
+		t.Fatalf("Scan does not work as expected: %s", id2)
+	}
+	if err = id2.Scan(int64(-1)); err != nil || id2 != StringID(^uint64(0)) {
+		t.Fatal("Scan does not work as expected with a negative BIGINT")
+	}
+}
+
+func TestID_MapKey(t *testing.T) {
+	m := map[ID]string{0xF00002A000000001: "foo"}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"17293825455320727553":"foo"}` {
+		t.Fatalf("the map key is not encoded as expected: %s", data)
+	}
+	var m2 map[ID]string
+	if err = json.Unmarshal(data, &m2); err != nil {
+		t.Fatal(err)
+	}
+	if m2[0xF00002A000000001] != "foo" {
+		t.Fatalf("the map key is not decoded as expected: %v", m2)
+	}
+
+	var id ID
+	if id.UnmarshalText([]byte("abc")) == nil {
+		t.Fatal("UnmarshalText should fail")
+	}
+}
+
+func TestID_Gob(t *testing.T) {
+	type payload struct {
+		ID  ID
+		SID StringID
+	}
+	var buf bytes.Buffer
+	in := payload{ID: 0xF00002A000000001, SID: 42}
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+	var out payload
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Fatalf("gob does not work as expected: %+v", out)
+	}
+
+	var id ID
+	if id.UnmarshalBinary([]byte{1, 2, 3}) == nil {
+		t.Fatal("UnmarshalBinary should fail")
+	}
+}
+
+func TestWUID_NextID(t *testing.T) {
+	g := NewWUID("default", nil, WithSection(3))
+	g.Reset(42 << 36)
+	id := g.NextID()
+	if id.Section() != 3 || id.H28()&0x00FFFFFF != 42 {
+		t.Fatalf("NextID does not work as expected: %s", id.Hex())
+	}
+}
@@ -0,0 +1,15 @@
+//go:build go1.23
+
+package internal
+
+import (
+	"iter"
+)
+
+// All is for internal use only.
+func (this *WUID) All() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		for yield(this.Next()) {
+		}
+	}
+}
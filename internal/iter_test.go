@@ -0,0 +1,25 @@
+//go:build go1.23
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestWUID_All(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.ResetH28(42)
+	var ids []uint64
+	for id := range g.All() {
+		ids = append(ids, id)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	if len(ids) != 3 || ids[0] != 42<<36+1 || ids[2] != 42<<36+3 {
+		t.Fatalf("All does not work as expected: %x", ids)
+	}
+	if n := g.Next(); n != 42<<36+4 {
+		t.Fatalf("All should not consume any number after break: %x", n)
+	}
+}
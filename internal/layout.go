@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Decompose is for internal use only.
+func Decompose(id uint64) (section uint8, h28 uint64, seq uint64) {
+	section = uint8(id >> 60)
+	h28 = id >> 36
+	if section != 0 {
+		h28 &= 0x00FFFFFF
+	}
+	seq = id & 0xFFFFFFFFF
+	return
+}
+
+// Layout is for internal use only.
+type Layout struct {
+	ID      uint64
+	Section uint8
+	H28     uint64
+	Seq     uint64
+}
+
+// Explain is for internal use only.
+func Explain(id uint64) Layout {
+	section, h28, seq := Decompose(id)
+	return Layout{ID: id, Section: section, H28: h28, Seq: seq}
+}
+
+// String describes every part of the ID, e.g.
+// 0x100002a000000005 = section 1 | h28 42 (0x00002a) | seq 5 (0x000000005).
+func (l Layout) String() string {
+	if l.Section == 0 {
+		return fmt.Sprintf("%#016x = h28 %d (%#07x) | seq %d (%#09x)", l.ID, l.H28, l.H28, l.Seq, l.Seq)
+	}
+	return fmt.Sprintf("%#016x = section %d | h28 %d (%#06x) | seq %d (%#09x)", l.ID, l.Section, l.H28, l.H28, l.Seq, l.Seq)
+}
+
+// BoundsForH28 is for internal use only.
+func BoundsForH28(h28 uint64, section uint8) (lo, hi uint64, err error) {
+	if section > 15 {
+		return 0, 0, fmt.Errorf("section must be in between [0, 15], while it is %d", section)
+	}
+	maxH28 := uint64(0x0FFFFFFF)
+	if section != 0 {
+		maxH28 = 0x00FFFFFF
+	}
+	if h28 == 0 || h28 > maxH28 {
+		return 0, 0, fmt.Errorf("h28 must be in between [1, %d], while it is %d", maxH28, h28)
+	}
+	lo = uint64(section)<<60 | h28<<36
+	return lo, lo | 0xFFFFFFFFF, nil
+}
+
+// LayoutVersion is for internal use only.
+func LayoutVersion(id uint64) uint8 {
+	return uint8(id >> (64 - LayoutVersionBits))
+}
+
+// Decompose is for internal use only.
+func (this *WUID) Decompose(id uint64) (section uint8, h28 uint64, seq uint64) {
+	if this.obfuscator != nil {
+		id = this.obfuscator.Deobfuscate(id)
+	}
+	if width := this.sectionWidth(); width != 0 {
+		shift := this.hBits + this.lowBits - this.versionBits - this.timeBits - width
+		section = uint8(id >> shift & (1<<width - 1))
+	}
+	h28 = id >> this.lowBits & this.MaxH28()
+	seq = id & this.lowMask
+	return
+}
+
+// BoundsForH28 is for internal use only.
+func (this *WUID) BoundsForH28(h28 uint64) (lo, hi uint64, err error) {
+	if this.obfuscator != nil || this.timeBits != 0 || this.snowflake != nil {
+		return 0, 0, errors.New("the blocks are not contiguous with WithObfuscation, WithTimePrefix or WithSnowflake. tag: " + this.Tag)
+	}
+	if max := this.MaxH28(); h28 == 0 || h28 > max {
+		return 0, 0, fmt.Errorf("h28 must be in between [1, %d], while it is %d. tag: %s", max, h28, this.Tag)
+	}
+	lo = this.stamp(h28 << this.lowBits)
+	return lo, lo | this.lowMask, nil
+}
+
+// LayoutVersion is for internal use only.
+func (this *WUID) LayoutVersion(id uint64) uint8 {
+	if this.versionBits == 0 {
+		return 0
+	}
+	return uint8(id >> (this.hBits + this.lowBits - this.versionBits) & (1<<this.versionBits - 1))
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestDecompose(t *testing.T) {
+	section, h28, seq := Decompose(0x100002A000000005)
+	if section != 1 || h28 != 42 || seq != 5 {
+		t.Fatalf("Decompose does not work as expected: %d, %d, %d", section, h28, seq)
+	}
+	section, h28, seq = Decompose(0x000002A000000005)
+	if section != 0 || h28 != 42 || seq != 5 {
+		t.Fatalf("Decompose does not work as expected: %d, %d, %d", section, h28, seq)
+	}
+
+	g := NewWUID("default", nil, WithSection(7))
+	g.Reset(12345 << 36)
+	g.Next()
+	section, h28, seq = Decompose(g.Next())
+	if section != 7 || h28 != 12345 || seq != 2 {
+		t.Fatalf("Decompose does not work as expected: %d, %d, %d", section, h28, seq)
+	}
+}
+
+func TestLayout_String(t *testing.T) {
+	cases := map[uint64]string{
+		0x100002A000000005: "0x100002a000000005 = section 1 | h28 42 (0x00002a) | seq 5 (0x000000005)",
+		0x000002A000000005: "0x000002a000000005 = h28 42 (0x000002a) | seq 5 (0x000000005)",
+	}
+	for id, str := range cases {
+		if s := Explain(id).String(); s != str {
+			t.Fatalf("Layout.String does not work as expected: %s", s)
+		}
+	}
+}
+
+func TestBoundsForH28(t *testing.T) {
+	lo, hi, err := BoundsForH28(42, 0)
+	if err != nil || lo != 0x000002A000000000 || hi != 0x000002AFFFFFFFFF {
+		t.Fatalf("BoundsForH28 does not work as expected: %x, %x, %v", lo, hi, err)
+	}
+	lo, hi, err = BoundsForH28(42, 1)
+	if err != nil || lo != 0x100002A000000000 || hi != 0x100002AFFFFFFFFF {
+		t.Fatalf("BoundsForH28 does not work as expected: %x, %x, %v", lo, hi, err)
+	}
+
+	g := NewWUID("default", nil, WithSection(7))
+	g.ResetH28(12345)
+	lo, hi, _ = BoundsForH28(12345, 7)
+	for i := 0; i < 10; i++ {
+		if n := g.Next(); n < lo || n > hi {
+			t.Fatalf("BoundsForH28 does not work as expected: %x, %x, %x", lo, n, hi)
+		}
+	}
+	if next, _, _ := BoundsForH28(12346, 7); next != hi+1 {
+		t.Fatal("the blocks should be adjacent")
+	}
+
+	for _, c := range []struct {
+		h28     uint64
+		section uint8
+	}{{0, 0}, {1 << 28, 0}, {1 << 24, 1}, {1, 16}} {
+		if _, _, err := BoundsForH28(c.h28, c.section); err == nil {
+			t.Fatalf("BoundsForH28 should fail. h28: %d, section: %d", c.h28, c.section)
+		}
+	}
+}
+
+func TestWUID_Decompose(t *testing.T) {
+	g := NewWUID("default", nil, WithBitLayout(24, 32), WithSection(3), WithLayoutVersion(1))
+	g.ResetH28(42)
+	g.Next()
+	n := g.Next()
+	if section, h28, seq := g.Decompose(n); section != 3 || h28 != 42 || seq != 2 {
+		t.Fatalf("Decompose does not work as expected: %d, %d, %d", section, h28, seq)
+	}
+	if v := g.LayoutVersion(n); v != 1 {
+		t.Fatalf("LayoutVersion does not work as expected: %d", v)
+	}
+
+	g = NewWUID("default", nil, WithObfuscation(testObfuscationKey))
+	g.ResetH28(42)
+	if section, h28, seq := g.Decompose(g.Next()); section != 0 || h28 != 42 || seq != 1 {
+		t.Fatalf("Decompose should deobfuscate the number first: %d, %d, %d", section, h28, seq)
+	}
+	if v := g.LayoutVersion(g.Next()); v != 0 {
+		t.Fatalf("LayoutVersion should return 0 without WithLayoutVersion: %d", v)
+	}
+}
+
+func TestWUID_BoundsForH28(t *testing.T) {
+	g := NewWUID("default", nil, WithBitLayout(24, 32), WithSection(3), WithLayoutVersion(1))
+	g.ResetH28(42)
+	lo, hi, err := g.BoundsForH28(42)
+	if err != nil || lo != 1<<54|3<<50|42<<32 || hi != lo|0xFFFFFFFF {
+		t.Fatalf("BoundsForH28 does not work as expected: %x, %x, %v", lo, hi, err)
+	}
+	for i := 0; i < 10; i++ {
+		if n := g.Next(); n < lo || n > hi {
+			t.Fatalf("BoundsForH28 does not work as expected: %x, %x, %x", lo, n, hi)
+		}
+	}
+	if next, _, _ := g.BoundsForH28(43); next != hi+1 {
+		t.Fatal("the blocks should be adjacent")
+	}
+	for _, h28 := range []uint64{0, 1 << 18} {
+		if _, _, err := g.BoundsForH28(h28); err == nil {
+			t.Fatalf("BoundsForH28 should fail. h28: %d", h28)
+		}
+	}
+
+	g = NewWUID("default", nil, WithObfuscation(testObfuscationKey))
+	if _, _, err := g.BoundsForH28(42); err == nil {
+		t.Fatal("BoundsForH28 should fail with WithObfuscation")
+	}
+}
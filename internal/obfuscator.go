@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/xtea"
+)
+
+// Obfuscator is for internal use only.
+type Obfuscator struct {
+	c *xtea.Cipher
+}
+
+// NewObfuscator is for internal use only.
+func NewObfuscator(key []byte) (*Obfuscator, error) {
+	if len(key) != 16 {
+		return nil, fmt.Errorf("the obfuscation key should be 16 bytes long, while it is %d bytes", len(key))
+	}
+	c, err := xtea.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Obfuscator{c: c}, nil
+}
+
+// Obfuscate maps n to another number with a keyed, reversible permutation.
+func (this *Obfuscator) Obfuscate(n uint64) uint64 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	this.c.Encrypt(b[:], b[:])
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// Deobfuscate reverses Obfuscate.
+func (this *Obfuscator) Deobfuscate(n uint64) uint64 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	this.c.Decrypt(b[:], b[:])
+	return binary.BigEndian.Uint64(b[:])
+}
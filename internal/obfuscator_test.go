@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var testObfuscationKey = []byte("0123456789abcdef")
+
+func TestNewObfuscator(t *testing.T) {
+	for _, key := range [][]byte{nil, []byte("short"), make([]byte, 17)} {
+		if _, err := NewObfuscator(key); err == nil {
+			t.Fatalf("NewObfuscator should fail. len(key): %d", len(key))
+		}
+	}
+	if _, err := NewObfuscator(testObfuscationKey); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestObfuscator(t *testing.T) {
+	o, _ := NewObfuscator(testObfuscationKey)
+	for i := 0; i < 10000; i++ {
+		n := rand.Uint64()
+		if v := o.Deobfuscate(o.Obfuscate(n)); v != n {
+			t.Fatalf("Obfuscator does not work as expected. n: %d, v: %d", n, v)
+		}
+	}
+	if o.Obfuscate(1<<36+1)>>36 == 1 {
+		t.Fatal("Obfuscate should hide the h28 value")
+	}
+}
+
+func TestWithObfuscation(t *testing.T) {
+	g := NewWUID("default", nil, WithObfuscation(testObfuscationKey))
+	g.Reset(1 << 36)
+	o, _ := NewObfuscator(testObfuscationKey)
+	if p := g.Peek(); o.Deobfuscate(p) != 1<<36+1 {
+		t.Fatalf("Peek does not work as expected: %x", p)
+	}
+	m := make(map[uint64]bool)
+	for i := uint64(1); i <= 1000; i++ {
+		v := g.Next()
+		if m[v] {
+			t.Fatalf("duplicate number: %x", v)
+		}
+		m[v] = true
+		if o.Deobfuscate(v) != 1<<36+i {
+			t.Fatalf("WithObfuscation does not work as expected. i: %d, v: %x", i, v)
+		}
+	}
+	if o.Deobfuscate(g.LastIssued()) != 1<<36+1000 {
+		t.Fatal("LastIssued does not work as expected")
+	}
+	for i, v := range g.NextN(10) {
+		if o.Deobfuscate(v) != 1<<36+1001+uint64(i) {
+			t.Fatalf("NextN does not work as expected. i: %d, v: %x", i, v)
+		}
+	}
+}
+
+func TestWithObfuscation_Panic(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithObfuscation(testObfuscationKey), WithJSSafe()},
+		{WithObfuscation(testObfuscationKey), WithSectionWidth(2)},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("NewWUID should panic")
+				}
+			}()
+			NewWUID("default", nil, opts...)
+		}()
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("WithObfuscation should panic")
+		}
+	}()
+	WithObfuscation([]byte("short"))
+}
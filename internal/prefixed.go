@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"errors"
+)
+
+// MaxPrefixLen is for internal use only.
+const MaxPrefixLen = 16
+
+// ValidatePrefix is for internal use only.
+func ValidatePrefix(prefix string) error {
+	if len(prefix) == 0 || len(prefix) > MaxPrefixLen {
+		return errors.New("the prefix should be 1 to 16 characters long: " + prefix)
+	}
+	if prefix[0] < 'a' || prefix[0] > 'z' {
+		return errors.New("the prefix should start with a lowercase letter: " + prefix)
+	}
+	for i := 1; i < len(prefix); i++ {
+		c := prefix[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return errors.New("the prefix should contain only lowercase letters and digits: " + prefix)
+		}
+	}
+	return nil
+}
+
+// FormatPrefixed is for internal use only.
+func FormatPrefixed(prefix string, n uint64) string {
+	return prefix + "_" + EncodeBase62(n)
+}
+
+// ParsePrefixed is for internal use only.
+func ParsePrefixed(prefix, s string) (uint64, error) {
+	return parsePrefixed(prefix, s, DecodeBase62, EncodeBase62)
+}
+
+// parsePrefixed checks the prefix of s, decodes the rest of it and makes sure that the rest is
+// in the canonical form.
+func parsePrefixed(prefix, s string, decode func(string) (uint64, error), encode func(uint64) string) (uint64, error) {
+	if len(s) <= len(prefix)+1 || s[:len(prefix)] != prefix || s[len(prefix)] != '_' {
+		return 0, errors.New("the prefixed ID does not start with " + prefix + "_: " + s)
+	}
+	rest := s[len(prefix)+1:]
+	n, err := decode(rest)
+	if err != nil {
+		return 0, err
+	}
+	if encode(n) != rest {
+		return 0, errors.New("the prefixed ID is not in the canonical form: " + s)
+	}
+	return n, nil
+}
+
+// NextPrefixed is for internal use only.
+func (this *WUID) NextPrefixed() string {
+	if this.prefix == "" {
+		panic("<wuid> NextPrefixed requires WithPrefix. tag: " + this.Tag)
+	}
+	if this.checkDigit {
+		return FormatPrefixedCheck(this.prefix, this.Next())
+	}
+	return FormatPrefixed(this.prefix, this.Next())
+}
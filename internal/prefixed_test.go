@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestValidatePrefix(t *testing.T) {
+	for _, prefix := range []string{"", "Ord", "1ord", "or_d", "ord-1", "abcdefghijklmnopq"} {
+		if err := ValidatePrefix(prefix); err == nil {
+			t.Fatalf("ValidatePrefix should fail. prefix: %q", prefix)
+		}
+	}
+	for _, prefix := range []string{"o", "ord", "user2", "abcdefghijklmnop"} {
+		if err := ValidatePrefix(prefix); err != nil {
+			t.Fatalf("ValidatePrefix should succeed. prefix: %q, err: %s", prefix, err)
+		}
+	}
+}
+
+func TestParsePrefixed(t *testing.T) {
+	for i := 0; i < 10000; i++ {
+		n := rand.Uint64()
+		v, err := ParsePrefixed("ord", FormatPrefixed("ord", n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("ParsePrefixed does not work as expected. n: %d, v: %d", n, v)
+		}
+	}
+	if s := FormatPrefixed("ord", math.MaxUint64); s != "ord_LygHa16AHYF" {
+		t.Fatalf("FormatPrefixed does not work as expected: %s", s)
+	}
+	for _, s := range []string{"", "ord", "ord_", "ordA", "usr_A", "ord_0A", "ord_A-B", "ord_LygHa16AHYG", "or_A"} {
+		if _, err := ParsePrefixed("ord", s); err == nil {
+			t.Fatalf("ParsePrefixed should fail. s: %q", s)
+		}
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	g := NewWUID("default", nil, WithPrefix("ord"))
+	g.Reset(1 << 36)
+	s := g.NextPrefixed()
+	if n, err := ParsePrefixed("ord", s); err != nil || n != 1<<36+1 {
+		t.Fatalf("WithPrefix does not work as expected: %s, %v", s, err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("WithPrefix should panic")
+			}
+		}()
+		WithPrefix("Ord")
+	}()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("NextPrefixed should panic")
+		}
+	}()
+	g = NewWUID("default", nil)
+	g.Reset(1 << 36)
+	g.NextPrefixed()
+}
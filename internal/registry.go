@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry is for internal use only.
+type Registry[T any] struct {
+	mu      sync.Mutex
+	entries map[string]*registryEntry[T]
+	closed  int32
+
+	newFn  func(tag string) T
+	load   func(g T, tag string) error
+	unwrap func(g T) *WUID
+}
+
+// registryEntry is locked while its generator is being loaded. loaded is set once g is ready, so
+// that the readers never wait behind a slow load. The registry lock is never taken while an entry
+// is locked.
+type registryEntry[T any] struct {
+	sync.Mutex
+	g      T
+	loaded int32
+}
+
+// NewRegistry is for internal use only.
+func NewRegistry[T any](newFn func(tag string) T, load func(g T, tag string) error, unwrap func(g T) *WUID) *Registry[T] {
+	return &Registry[T]{
+		entries: make(map[string]*registryEntry[T]),
+		newFn:   newFn,
+		load:    load,
+		unwrap:  unwrap,
+	}
+}
+
+// Get is for internal use only.
+func (this *Registry[T]) Get(tag string) (T, error) {
+	var zero T
+	this.mu.Lock()
+	if atomic.LoadInt32(&this.closed) != 0 {
+		this.mu.Unlock()
+		return zero, errors.New("the registry has been closed. tag: " + tag)
+	}
+	e := this.entries[tag]
+	if e == nil {
+		e = &registryEntry[T]{}
+		this.entries[tag] = e
+	}
+	this.mu.Unlock()
+
+	if atomic.LoadInt32(&e.loaded) != 0 {
+		return e.g, nil
+	}
+	e.Lock()
+	defer e.Unlock()
+	if atomic.LoadInt32(&e.loaded) != 0 {
+		return e.g, nil
+	}
+	g := this.newFn(tag)
+	if err := this.load(g, tag); err != nil {
+		return zero, err
+	}
+	if atomic.LoadInt32(&this.closed) != 0 {
+		_ = this.unwrap(g).Close()
+		return zero, errors.New("the registry has been closed. tag: " + tag)
+	}
+	e.g = g
+	atomic.StoreInt32(&e.loaded, 1)
+	return g, nil
+}
+
+// Tags is for internal use only.
+func (this *Registry[T]) Tags() []string {
+	var tags []string
+	for tag := range this.loadedEntries() {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// loadedEntries returns a snapshot of the entries whose generators have been loaded.
+func (this *Registry[T]) loadedEntries() map[string]*registryEntry[T] {
+	this.mu.Lock()
+	entries := make(map[string]*registryEntry[T], len(this.entries))
+	for tag, e := range this.entries {
+		entries[tag] = e
+	}
+	this.mu.Unlock()
+
+	for tag, e := range entries {
+		if atomic.LoadInt32(&e.loaded) == 0 {
+			delete(entries, tag)
+		}
+	}
+	return entries
+}
+
+// RenewAll is for internal use only.
+func (this *Registry[T]) RenewAll() error {
+	entries := this.loadedEntries()
+	tags := make([]string, 0, len(entries))
+	for tag := range entries {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var errs []error
+	for _, tag := range tags {
+		if err := this.unwrap(entries[tag].g).RenewNow(); err != nil {
+			errs = append(errs, fmt.Errorf("tag %s: %w", tag, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CloseAll is for internal use only.
+func (this *Registry[T]) CloseAll() error {
+	this.mu.Lock()
+	entries := this.entries
+	this.entries = make(map[string]*registryEntry[T])
+	atomic.StoreInt32(&this.closed, 1)
+	this.mu.Unlock()
+
+	var errs []error
+	for tag, e := range entries {
+		e.Lock()
+		if atomic.LoadInt32(&e.loaded) != 0 {
+			if err := this.unwrap(e.g).Close(); err != nil {
+				errs = append(errs, fmt.Errorf("tag %s: %w", tag, err))
+			}
+		}
+		e.Unlock()
+	}
+	return errors.Join(errs...)
+}
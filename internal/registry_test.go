@@ -0,0 +1,209 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestRegistry(loads *int32, fail func(tag string) bool) *Registry[*WUID] {
+	var h28 uint64
+	newFn := func(tag string) *WUID {
+		return NewWUID(tag, nil)
+	}
+	load := func(g *WUID, tag string) error {
+		atomic.AddInt32(loads, 1)
+		if fail(tag) {
+			return errors.New("load failed. tag: " + tag)
+		}
+		g.Renew = func() error {
+			g.Reset(atomic.AddUint64(&h28, 1) << 36)
+			return nil
+		}
+		return g.Renew()
+	}
+	unwrap := func(g *WUID) *WUID {
+		return g
+	}
+	return NewRegistry(newFn, load, unwrap)
+}
+
+func TestRegistry_Get(t *testing.T) {
+	var loads int32
+	r := newTestRegistry(&loads, func(string) bool { return false })
+
+	var wg sync.WaitGroup
+	gs := make([]*WUID, 10)
+	for i := range gs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g, err := r.Get("alpha")
+			if err != nil {
+				t.Error(err)
+			}
+			gs[i] = g
+		}(i)
+	}
+	wg.Wait()
+	for _, g := range gs {
+		if g != gs[0] || g.Tag != "alpha" {
+			t.Fatal("Get should return the same generator for the same tag")
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("the generator should be loaded only once: %d", loads)
+	}
+
+	g, err := r.Get("beta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g == gs[0] || g.Next()>>36 == gs[0].Next()>>36 {
+		t.Fatal("Get should return different generators for different tags")
+	}
+	if tags := r.Tags(); strings.Join(tags, ",") != "alpha,beta" {
+		t.Fatalf("Tags does not work as expected: %v", tags)
+	}
+}
+
+func TestRegistry_Get_Error(t *testing.T) {
+	var loads int32
+	var failing int32 = 1
+	r := newTestRegistry(&loads, func(string) bool { return atomic.LoadInt32(&failing) == 1 })
+
+	if _, err := r.Get("alpha"); err == nil {
+		t.Fatal("Get should fail when load fails")
+	}
+	if len(r.Tags()) != 0 {
+		t.Fatal("a generator that failed to load should not be listed")
+	}
+	atomic.StoreInt32(&failing, 0)
+	if _, err := r.Get("alpha"); err != nil {
+		t.Fatal(err)
+	}
+	if loads != 2 {
+		t.Fatalf("a failed load should not be cached: %d", loads)
+	}
+}
+
+func TestRegistry_RenewAll(t *testing.T) {
+	var loads int32
+	r := newTestRegistry(&loads, func(string) bool { return false })
+	g1, _ := r.Get("alpha")
+	g2, _ := r.Get("beta")
+	n1, n2 := g1.Next(), g2.Next()
+	if err := r.RenewAll(); err != nil {
+		t.Fatal(err)
+	}
+	if g1.Next()>>36 == n1>>36 || g2.Next()>>36 == n2>>36 {
+		t.Fatal("RenewAll does not work as expected")
+	}
+
+	g2.Renew = func() error {
+		return errors.New("foo")
+	}
+	err := r.RenewAll()
+	if err == nil || !strings.Contains(err.Error(), "tag beta: foo") {
+		t.Fatalf("RenewAll should report the failed tags: %v", err)
+	}
+}
+
+func TestRegistry_SlowLoad(t *testing.T) {
+	var h28 uint64
+	started, release := make(chan struct{}), make(chan struct{})
+	newFn := func(tag string) *WUID {
+		return NewWUID(tag, nil)
+	}
+	load := func(g *WUID, tag string) error {
+		if tag == "slow" {
+			close(started)
+			<-release
+		}
+		g.Renew = func() error {
+			g.Reset(atomic.AddUint64(&h28, 1) << 36)
+			return nil
+		}
+		return g.Renew()
+	}
+	r := NewRegistry(newFn, load, func(g *WUID) *WUID { return g })
+	if _, err := r.Get("alpha"); err != nil {
+		t.Fatal(err)
+	}
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := r.Get("slow")
+		slow <- err
+	}()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				if tags := r.Tags(); len(tags) != 1 || tags[0] != "alpha" {
+					t.Errorf("unexpected tags: %v", tags)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if err := r.RenewAll(); err != nil {
+					t.Error(err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := r.Get("alpha"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Tags, RenewAll and Get should not wait behind a slow load")
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatal(err)
+	}
+	if tags := r.Tags(); len(tags) != 2 {
+		t.Fatalf("unexpected tags: %v", tags)
+	}
+	if err := r.CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistry_CloseAll(t *testing.T) {
+	var loads int32
+	r := newTestRegistry(&loads, func(string) bool { return false })
+	g, _ := r.Get("alpha")
+	if err := r.CloseAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Get("alpha"); err == nil {
+		t.Fatal("Get should fail after CloseAll is called")
+	}
+	if len(r.Tags()) != 0 {
+		t.Fatal("CloseAll should drop all generators")
+	}
+	if err := g.RenewNow(); err == nil {
+		t.Fatal("the renew of a closed generator should fail")
+	}
+	if _, err := g.NextCtx(context.Background()); err == nil {
+		t.Fatal("CloseAll should close all generators")
+	}
+}
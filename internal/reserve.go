@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ReserveRange is for internal use only.
+func (this *WUID) ReserveRange(n uint64) (start, end uint64, err error) {
+	if n == 0 {
+		return 0, 0, errors.New("n should be greater than 0. tag: " + this.Tag)
+	}
+	if this.step != 1 || this.skip != nil || this.obfuscator != nil || this.timeBits != 0 || this.snowflake != nil {
+		return 0, 0, errors.New("the ranges are not contiguous with WithStep, the skip options, WithObfuscation, " +
+			"WithTimePrefix or WithSnowflake. tag: " + this.Tag)
+	}
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return 0, 0, fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+	}
+	if this.H28() == 0 {
+		return 0, 0, fmt.Errorf("%w. tag: %s", ErrNotLoaded, this.Tag)
+	}
+
+	// Take the range from the current block if it fits below the critical value, so that the
+	// renew is not affected.
+	for {
+		x := atomic.LoadUint64(&this.N)
+		v := x & this.lowMask
+		if v >= this.criticalValue || n > this.criticalValue-v {
+			break
+		}
+		if atomic.CompareAndSwapUint64(&this.N, x, x+n) {
+			return x + 1, x + n, nil
+		}
+	}
+
+	this.Lock()
+	reserve := this.ReserveH28
+	this.Unlock()
+	if reserve == nil {
+		return 0, 0, errors.New("the range does not fit in the current block, and the loader cannot reserve h28 values in bulk. tag: " + this.Tag)
+	}
+
+	blockSize := this.lowMask + 1
+	k := (n-1)/blockSize + 1
+	if k > this.MaxH28() {
+		return 0, 0, fmt.Errorf("the range is too large: %d. tag: %s", n, this.Tag)
+	}
+	last, err := reserve(k)
+	if err != nil {
+		return 0, 0, err
+	}
+	first := last - k + 1
+	if err = this.VerifyH28(first); err != nil {
+		return 0, 0, err
+	}
+	if err = this.VerifyH28(last); err != nil {
+		return 0, 0, err
+	}
+
+	start = this.stamp(first << this.lowBits)
+	this.Logger.Info(fmt.Sprintf("<wuid> reserved h28: [%d, %d]. tag: %s", first, last, this.Tag))
+	return start, start + n - 1, nil
+}
+
+// renewFromBlocks hands out the h28 values reserved by the last round trip one at a time, and
+// reserves the next batch of them once they run out.
+func (this *WUID) renewFromBlocks(reserve func(k uint64) (last uint64, err error)) error {
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+	}
+
+	this.resetMu.Lock()
+	h28 := this.nextBlock
+	if h28 != 0 && h28 <= this.lastBlock {
+		this.nextBlock++
+	} else {
+		h28 = 0
+	}
+	this.resetMu.Unlock()
+
+	if h28 == 0 {
+		k := this.blocksPerRenew
+		last, err := reserve(k)
+		if err != nil {
+			return err
+		}
+		first := last - k + 1
+		if err = this.VerifyH28(first); err != nil {
+			return err
+		}
+		if err = this.VerifyH28(last); err != nil {
+			return err
+		}
+		this.Logger.Info(fmt.Sprintf("<wuid> reserved h28: [%d, %d]. tag: %s", first, last, this.Tag))
+
+		this.resetMu.Lock()
+		this.nextBlock, this.lastBlock = first+1, last
+		this.resetMu.Unlock()
+		h28 = first
+	}
+
+	this.ResetH28(h28)
+	this.Logger.Info(fmt.Sprintf("<wuid> new h28: %d. tag: %s", h28, this.Tag))
+	return nil
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWUID_ReserveRange(t *testing.T) {
+	g := NewWUID("default", nil, WithSection(1))
+	if _, _, err := g.ReserveRange(10); err == nil {
+		t.Fatal("ReserveRange should fail before the WUID is loaded")
+	}
+	g.ResetH28(42)
+	g.Next()
+	start, end, err := g.ReserveRange(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 1<<60|42<<36+2 || end != start+999 || g.Next() != end+1 {
+		t.Fatalf("ReserveRange does not work as expected: %x, %x", start, end)
+	}
+
+	if _, _, err := g.ReserveRange(CriticalValue); err == nil {
+		t.Fatal("ReserveRange should fail without ReserveH28")
+	}
+
+	var counter uint64 = 100
+	g.ReserveH28 = func(k uint64) (uint64, error) {
+		counter += k
+		return counter, nil
+	}
+	n := uint64(1<<36)*2 + 5
+	start, end, err = g.ReserveRange(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 1<<60|101<<36 || end != start+n-1 || end>>36&g.MaxH28() != 103 || counter != 103 {
+		t.Fatalf("ReserveRange does not work as expected: %x, %x", start, end)
+	}
+	if g.H28() != 42 {
+		t.Fatal("ReserveRange should not affect the current block")
+	}
+
+	counter = g.MaxH28()
+	if _, _, err := g.ReserveRange(n); err == nil {
+		t.Fatal("ReserveRange should fail when the h28 values run out")
+	}
+	if _, _, err := g.ReserveRange(0); err == nil {
+		t.Fatal("ReserveRange should fail when n is 0")
+	}
+	if _, _, err := NewWUID("default", nil, WithStep(2)).ReserveRange(10); err == nil {
+		t.Fatal("ReserveRange should fail with WithStep")
+	}
+}
+
+func TestWithBlocksPerRenew(t *testing.T) {
+	var counter, trips uint64 = 10, 0
+	g := NewWUID("default", nil, WithBlocksPerRenew(3))
+	g.Renew = func() error {
+		t.Fatal("Renew should not be called when ReserveH28 is available")
+		return nil
+	}
+	g.ReserveH28 = func(k uint64) (uint64, error) {
+		trips++
+		counter += k
+		return counter, nil
+	}
+	g.ResetH28(counter)
+	for i, expected := range []uint64{11, 12, 13, 14, 15, 16, 17} {
+		if err := g.RenewNow(); err != nil {
+			t.Fatal(err)
+		}
+		if g.H28() != expected {
+			t.Fatalf("WithBlocksPerRenew does not work as expected. i: %d, h28: %d", i, g.H28())
+		}
+	}
+	if trips != 3 {
+		t.Fatalf("there should be 3 round trips: %d", trips)
+	}
+
+	_ = g.Close()
+	if err := g.RenewNow(); err == nil {
+		t.Fatal("RenewNow should fail after Close")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("WithBlocksPerRenew should panic")
+		}
+	}()
+	WithBlocksPerRenew(0)
+}
+
+func TestWithBlocksPerRenew_Unsupported(t *testing.T) {
+	g := NewWUID("default", nil, WithBlocksPerRenew(3))
+	g.Renew = func() error {
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+	g.ResetH28(10)
+	err := g.RenewNow()
+	if err == nil || !strings.Contains(err.Error(), "WithBlocksPerRenew") {
+		t.Fatalf("RenewNow should fail when ReserveH28 is unavailable: %v", err)
+	}
+	if g.H28() != 10 {
+		t.Fatalf("Renew should not be called when ReserveH28 is unavailable. h28: %d", g.H28())
+	}
+	if g.BlocksPerRenew() != 3 {
+		t.Fatal("BlocksPerRenew does not work as expected")
+	}
+}
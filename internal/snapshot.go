@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+const (
+	snapshotMagic   = "wuid"
+	snapshotVersion = 4
+	snapshotHeader  = len(snapshotMagic) + 1 + 9 + 8 + 8
+)
+
+// Snapshot is for internal use only.
+func (this *WUID) Snapshot() []byte {
+	b := make([]byte, 0, snapshotHeader+len(this.Tag))
+	b = append(b, snapshotMagic...)
+	b = append(b, snapshotVersion, this.Section, this.sectionBits, this.region, this.regionBits, this.timeBits,
+		this.layoutVersion, this.versionBits, this.hBits, this.lowBits)
+	b = binary.BigEndian.AppendUint64(b, this.step)
+	n := atomic.LoadUint64(&this.N)
+	if atomic.LoadInt32(&this.closed) != 0 {
+		n = atomic.LoadUint64(&this.finalN)
+	}
+	b = binary.BigEndian.AppendUint64(b, n)
+	b = append(b, this.Tag...)
+	return b
+}
+
+// Restore is for internal use only.
+func (this *WUID) Restore(data []byte, renew func() error) error {
+	if renew == nil {
+		return errors.New("renew cannot be nil. tag: " + this.Tag)
+	}
+	if len(data) < snapshotHeader || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return errors.New("the snapshot is malformed. tag: " + this.Tag)
+	}
+	data = data[len(snapshotMagic):]
+	if data[0] != snapshotVersion {
+		return fmt.Errorf("the snapshot version %d is not supported. tag: %s", data[0], this.Tag)
+	}
+	section, sectionBits, region, regionBits := data[1], data[2], data[3], data[4]
+	timeBits, version, versionBits, hBits, lowBits := data[5], data[6], data[7], data[8], data[9]
+	step := binary.BigEndian.Uint64(data[10:])
+	n := binary.BigEndian.Uint64(data[18:])
+	tag := string(data[26:])
+
+	switch {
+	case tag != this.Tag:
+		return fmt.Errorf("the snapshot belongs to another tag: %s. tag: %s", tag, this.Tag)
+	case section != this.Section:
+		return fmt.Errorf("the section ID of the snapshot is %d, while it should be %d. tag: %s", section, this.Section, this.Tag)
+	case sectionBits != this.sectionBits:
+		return fmt.Errorf("the section width of the snapshot is %d, while it should be %d. tag: %s", sectionBits, this.sectionBits, this.Tag)
+	case region != this.region || regionBits != this.regionBits:
+		return fmt.Errorf("the region of the snapshot is %d/%d, while it should be %d/%d. tag: %s",
+			region, regionBits, this.region, this.regionBits, this.Tag)
+	case timeBits != this.timeBits:
+		return fmt.Errorf("the time prefix of the snapshot is %d bits, while it should be %d bits. tag: %s", timeBits, this.timeBits, this.Tag)
+	case version != this.layoutVersion || versionBits != this.versionBits:
+		return fmt.Errorf("the layout version of the snapshot is %d/%d, while it should be %d/%d. tag: %s",
+			version, versionBits, this.layoutVersion, this.versionBits, this.Tag)
+	case hBits != this.hBits || lowBits != this.lowBits:
+		return fmt.Errorf("the layout of the snapshot is %d/%d, while it should be %d/%d. tag: %s",
+			hBits, lowBits, this.hBits, this.lowBits, this.Tag)
+	case step != this.step:
+		return fmt.Errorf("the step of the snapshot is %d, while it should be %d. tag: %s", step, this.step, this.Tag)
+	}
+	if this.H28() != 0 {
+		return errors.New("the snapshot should be restored before the WUID is loaded. tag: " + this.Tag)
+	}
+	if err := this.VerifyH28(n >> this.lowBits & this.MaxH28()); err != nil {
+		return err
+	}
+
+	this.Reset(n)
+	this.Logger.Info(fmt.Sprintf("<wuid> restored h28: %d. tag: %s", n>>this.lowBits&this.MaxH28(), this.Tag))
+
+	this.Lock()
+	this.Renew = renew
+	this.Unlock()
+
+	if n&this.lowMask >= this.criticalValue {
+		go this.renew()
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWUID_Snapshot(t *testing.T) {
+	g1 := NewWUID("default", nil, WithSection(3), WithStep(2))
+	g1.ResetH28(42)
+	g1.NextN(10)
+	data := g1.Snapshot()
+
+	g2 := NewWUID("default", nil, WithSection(3), WithStep(2))
+	if err := g2.Restore(data, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if n1, n2 := g1.Next(), g2.Next(); n1 != n2 {
+		t.Fatalf("Restore does not work as expected: %x, %x", n1, n2)
+	}
+	if err := g2.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Restore(data, func() error { return nil }); err == nil {
+		t.Fatal("Restore should fail when the WUID has been loaded")
+	}
+}
+
+func TestWUID_Restore_Renew(t *testing.T) {
+	g1 := NewWUID("default", nil)
+	g1.Reset(42<<36 | CriticalValue)
+	data := g1.Snapshot()
+
+	logger := &simpleLogger{}
+	g2 := NewWUID("default", logger)
+	err := g2.Restore(data, func() error {
+		g2.ResetH28(43)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 200)
+	if g2.H28() != 43 {
+		t.Fatalf("Restore should renew when the restored block is about to run out. h28: %d", g2.H28())
+	}
+}
+
+func TestWUID_Restore_Error(t *testing.T) {
+	renew := func() error {
+		return errors.New("foo")
+	}
+	g := NewWUID("default", nil)
+	g.ResetH28(42)
+	data := g.Snapshot()
+
+	if err := NewWUID("default", nil).Restore(data, nil); err == nil {
+		t.Fatal("Restore should fail when renew is nil")
+	}
+	if err := NewWUID("default", nil).Restore(data[:10], renew); err == nil {
+		t.Fatal("Restore should fail when the snapshot is truncated")
+	}
+	bad := append([]byte(nil), data...)
+	bad[4] = 99
+	if err := NewWUID("default", nil).Restore(bad, renew); err == nil {
+		t.Fatal("Restore should fail when the version is not supported")
+	}
+	if err := NewWUID("other", nil).Restore(data, renew); err == nil {
+		t.Fatal("Restore should fail when the tag does not match")
+	}
+	if err := NewWUID("default", nil, WithSection(1)).Restore(data, renew); err == nil {
+		t.Fatal("Restore should fail when the section ID does not match")
+	}
+	if err := NewWUID("default", nil, WithSectionWidth(4)).Restore(data, renew); err == nil {
+		t.Fatal("Restore should fail when the section width does not match")
+	}
+	if err := NewWUID("default", nil, WithJSSafe()).Restore(data, renew); err == nil {
+		t.Fatal("Restore should fail when the layout does not match")
+	}
+	if err := NewWUID("default", nil, WithStep(2)).Restore(data, renew); err == nil {
+		t.Fatal("Restore should fail when the step does not match")
+	}
+	if err := NewWUID("default", nil).Restore(NewWUID("default", nil).Snapshot(), renew); err == nil {
+		t.Fatal("Restore should fail when the snapshot has no h28")
+	}
+}
+
+func TestWUID_Snapshot_Close(t *testing.T) {
+	g1 := NewWUID("default", nil)
+	g1.ResetH28(42)
+	n1 := g1.Next()
+	_ = g1.Close()
+
+	g2 := NewWUID("default", nil)
+	if err := g2.Restore(g1.Snapshot(), func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if n2 := g2.Next(); n2 != n1+1 {
+		t.Fatalf("Snapshot does not work as expected after Close is called: %x, %x", n1, n2)
+	}
+}
+
+func TestWUID_Snapshot_Region(t *testing.T) {
+	g1 := NewWUID("default", nil, WithRegion(1, 2))
+	g1.ResetH28(42)
+	g2 := NewWUID("default", nil, WithRegion(2, 2))
+	if err := g2.Restore(g1.Snapshot(), func() error { return nil }); err == nil {
+		t.Fatal("Restore should fail when the region does not match")
+	}
+	g3 := NewWUID("default", nil, WithRegion(1, 2))
+	if err := g3.Restore(g1.Snapshot(), func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if g3.Next() != g1.Next() {
+		t.Fatal("Restore does not work as expected with WithRegion")
+	}
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// SnowflakeWorkerBits is the number of the worker bits in the Snowflake layout
+	SnowflakeWorkerBits = 10
+	// SnowflakeSeqBits is the number of the sequence bits in the Snowflake layout
+	SnowflakeSeqBits = 12
+)
+
+// SnowflakeEpoch is for internal use only.
+var SnowflakeEpoch = time.UnixMilli(1288834974657)
+
+type snowflake struct {
+	epoch int64
+	// state holds the timestamp of the last number in the high bits and its sequence number in
+	// the low 12 bits. When the sequence numbers run out, the timestamp is borrowed from the next
+	// millisecond.
+	state uint64
+}
+
+func (this *snowflake) now() uint64 {
+	ms := time.Now().UnixMilli() - this.epoch
+	if ms < 0 {
+		return 0
+	}
+	return uint64(ms)
+}
+
+func (this *snowflake) following(state uint64) uint64 {
+	if now := this.now(); now > state>>SnowflakeSeqBits {
+		return now << SnowflakeSeqBits
+	}
+	return state + 1
+}
+
+func (this *snowflake) next() uint64 {
+	for {
+		old := atomic.LoadUint64(&this.state)
+		state := this.following(old)
+		if atomic.CompareAndSwapUint64(&this.state, old, state) {
+			return state
+		}
+	}
+}
+
+// compose puts the worker ID between the timestamp and the sequence number of state.
+func compose(state, worker uint64) uint64 {
+	const seqMask = 1<<SnowflakeSeqBits - 1
+	const workerMask = 1<<SnowflakeWorkerBits - 1
+	return state>>SnowflakeSeqBits<<(SnowflakeWorkerBits+SnowflakeSeqBits) |
+		(worker&workerMask)<<SnowflakeSeqBits | state&seqMask
+}
+
+// nextSnowflake returns the next unique number in the Snowflake layout. The worker ID is the
+// lowest 10 bits of the h28.
+func (this *WUID) nextSnowflake() uint64 {
+	if atomic.LoadInt32(&this.closed) != 0 {
+		panic("<wuid> the generator has been closed. tag: " + this.Tag)
+	}
+	return compose(this.snowflake.next(), this.H28())
+}
+
+// DecomposeSnowflake is for internal use only.
+func DecomposeSnowflake(id uint64, epoch time.Time) (t time.Time, worker uint64, seq uint64) {
+	ms := int64(id >> (SnowflakeWorkerBits + SnowflakeSeqBits))
+	t = epoch.Add(time.Duration(ms) * time.Millisecond)
+	worker = id >> SnowflakeSeqBits & (1<<SnowflakeWorkerBits - 1)
+	seq = id & (1<<SnowflakeSeqBits - 1)
+	return
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWithSnowflake(t *testing.T) {
+	g := NewWUID("default", nil, WithSnowflake(time.Time{}))
+	g.ResetH28(0x1403)
+	if g.LastIssued() != 0 {
+		t.Fatal("LastIssued does not work as expected")
+	}
+	start := time.Now().Truncate(time.Millisecond)
+	a := g.NextN(10000)
+	if !sort.SliceIsSorted(a, func(i, j int) bool { return a[i] < a[j] }) {
+		t.Fatal("the numbers are not sorted")
+	}
+	for i := 1; i < len(a); i++ {
+		if a[i] == a[i-1] {
+			t.Fatalf("duplicate number: %x", a[i])
+		}
+	}
+	if g.LastIssued() != a[len(a)-1] || g.Peek() <= a[len(a)-1] {
+		t.Fatal("LastIssued or Peek does not work as expected")
+	}
+
+	ts, worker, seq := DecomposeSnowflake(a[0], SnowflakeEpoch)
+	if ts.Before(start) || ts.After(time.Now()) || worker != 3 || seq >= 1<<SnowflakeSeqBits {
+		t.Fatalf("DecomposeSnowflake does not work as expected: %s, %d, %d", ts, worker, seq)
+	}
+	if a[0]>>63 != 0 {
+		t.Fatal("the sign bit should be 0")
+	}
+	if n, err := g.NextE(); err != nil || n <= a[len(a)-1] {
+		t.Fatalf("NextE does not work as expected: %x, %v", n, err)
+	}
+}
+
+func TestWithSnowflake_Borrow(t *testing.T) {
+	g := NewWUID("default", nil, WithSnowflake(time.Now().Add(time.Hour)))
+	g.ResetH28(1)
+	n1 := g.Next()
+	n2 := g.Next()
+	if n1 != 1<<SnowflakeSeqBits|1 || n2 != n1+1 {
+		t.Fatalf("the sequence numbers do not work as expected: %x, %x", n1, n2)
+	}
+}
+
+func TestWithSnowflake_Panic(t *testing.T) {
+	cases := []func(){
+		func() { NewWUID("default", nil, WithSnowflake(time.Time{}), WithSection(1)) },
+		func() { NewWUID("default", nil, WithSnowflake(time.Time{}), WithStep(2)) },
+		func() { NewWUID("default", nil, WithSnowflake(time.Time{}), WithJSSafe()) },
+		func() {
+			g := NewWUID("default", nil, WithSnowflake(time.Time{}))
+			g.ResetH28(1)
+			_ = g.Close()
+			g.Next()
+		},
+	}
+	for i, fn := range cases {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			fn()
+			t.Fatalf("case %d should panic", i)
+		}()
+	}
+}
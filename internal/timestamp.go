@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+// Timestamp is for internal use only.
+func (this *WUID) Timestamp(id uint64) (time.Time, error) {
+	switch {
+	case this.snowflake != nil:
+		ms := int64(id >> (SnowflakeWorkerBits + SnowflakeSeqBits))
+		return time.UnixMilli(this.snowflake.epoch + ms), nil
+	case this.timeBits != 0:
+		bucket := id >> this.timeShift() & (1<<this.timeBits - 1)
+		return TimePrefixEpoch.Add(time.Duration(bucket) * this.timeUnit), nil
+	default:
+		return time.Time{}, errors.New("the IDs carry no timestamp without WithTimePrefix or WithSnowflake. tag: " + this.Tag)
+	}
+}
+
+// IDRangeForInterval is for internal use only.
+func (this *WUID) IDRangeForInterval(from, to time.Time) (lo, hi uint64, err error) {
+	if to.Before(from) {
+		return 0, 0, errors.New("to should not be earlier than from. tag: " + this.Tag)
+	}
+	switch {
+	case this.snowflake != nil:
+		const shift = SnowflakeWorkerBits + SnowflakeSeqBits
+		ms1 := max(from.UnixMilli()-this.snowflake.epoch, 0)
+		ms2 := max(to.UnixMilli()-this.snowflake.epoch, 0)
+		return uint64(ms1) << shift, uint64(ms2+1)<<shift - 1, nil
+	case this.timeBits != 0:
+		b1, b2 := this.timeBucket(from), this.timeBucket(to)
+		if b2 < b1 {
+			return 0, 0, errors.New("the time prefix wraps around within the interval. tag: " + this.Tag)
+		}
+		shift := this.timeShift()
+		version := uint64(this.layoutVersion) << (shift + this.timeBits)
+		return version | b1<<shift, version | ((b2+1)<<shift - 1), nil
+	default:
+		return 0, 0, errors.New("the IDs carry no timestamp without WithTimePrefix or WithSnowflake. tag: " + this.Tag)
+	}
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWUID_Timestamp(t *testing.T) {
+	g := NewWUID("default", nil, WithSnowflake(time.Time{}))
+	g.ResetH28(5)
+	before := time.Now().Truncate(time.Millisecond)
+	n := g.Next()
+	ts, err := g.Timestamp(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Before(before) || ts.After(time.Now()) {
+		t.Fatalf("Timestamp does not work as expected: %s", ts)
+	}
+	lo, hi, err := g.IDRangeForInterval(before, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n < lo || n > hi {
+		t.Fatalf("IDRangeForInterval does not work as expected: %x, %x, %x", lo, n, hi)
+	}
+
+	g = NewWUID("default", nil, WithTimePrefix(16, time.Hour))
+	g.ResetH28(5)
+	before = time.Now().Truncate(time.Hour)
+	n = g.Next()
+	ts, err = g.Timestamp(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.Before(before) || ts.After(time.Now()) {
+		t.Fatalf("Timestamp does not work as expected: %s", ts)
+	}
+	lo, hi, err = g.IDRangeForInterval(before, before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lo != hi-(1<<48-1) || n < lo || n > hi {
+		t.Fatalf("IDRangeForInterval does not work as expected: %x, %x, %x", lo, n, hi)
+	}
+}
+
+func TestWUID_Timestamp_Error(t *testing.T) {
+	g := NewWUID("default", nil)
+	if _, err := g.Timestamp(42); err == nil {
+		t.Fatal("Timestamp should fail without a timestamp")
+	}
+	if _, _, err := g.IDRangeForInterval(time.Now(), time.Now()); err == nil {
+		t.Fatal("IDRangeForInterval should fail without a timestamp")
+	}
+	g = NewWUID("default", nil, WithTimePrefix(1, time.Hour))
+	now := time.Now()
+	if _, _, err := g.IDRangeForInterval(now, now.Add(-time.Second)); err == nil {
+		t.Fatal("IDRangeForInterval should fail when to is earlier than from")
+	}
+	from := TimePrefixEpoch.Add(time.Hour)
+	if _, _, err := g.IDRangeForInterval(from, from.Add(time.Hour)); err == nil {
+		t.Fatal("IDRangeForInterval should fail when the time prefix wraps around")
+	}
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"errors"
+	"time"
+)
+
+// FormatULID is for internal use only.
+func FormatULID(ms uint64, n uint64) string {
+	hi, lo := ms<<16, n
+	var buf [26]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = crockfordAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(buf[:])
+}
+
+// ParseULID is for internal use only.
+func ParseULID(s string) (ms uint64, n uint64, err error) {
+	if len(s) != 26 {
+		return 0, 0, errors.New("a ULID should be 26 characters long: " + s)
+	}
+	var hi, lo uint64
+	for i := 0; i < len(s); i++ {
+		d := crockfordIndex[s[i]]
+		if d < 0 {
+			return 0, 0, errors.New("invalid ULID: " + s)
+		}
+		if i == 0 && d > 7 {
+			return 0, 0, errors.New("the ULID overflows 128 bits: " + s)
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(d)
+	}
+	if hi&0xFFFF != 0 {
+		return 0, 0, errors.New("the ULID was not generated by WUID: " + s)
+	}
+	return hi >> 16, lo, nil
+}
+
+// NextULID is for internal use only.
+func (this *WUID) NextULID() string {
+	return FormatULID(uint64(time.Now().UnixMilli()), this.Next())
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestFormatULID(t *testing.T) {
+	cases := []struct {
+		ms, n uint64
+		s     string
+	}{
+		{0, 0, "00000000000000000000000000"},
+		{0, 1, "00000000000000000000000001"},
+		{1<<48 - 1, math.MaxUint64, "7ZZZZZZZZZ000FZZZZZZZZZZZZ"},
+		{1469918176385, 0, "01ARYZ6S410000000000000000"},
+	}
+	for _, c := range cases {
+		if s := FormatULID(c.ms, c.n); s != c.s {
+			t.Fatalf("FormatULID(%d, %d) is %s, while it should be %s", c.ms, c.n, s, c.s)
+		}
+	}
+}
+
+func TestParseULID(t *testing.T) {
+	for i := 0; i < 10000; i++ {
+		ms, n := rand.Uint64()>>16, rand.Uint64()
+		ms2, n2, err := ParseULID(FormatULID(ms, n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ms2 != ms || n2 != n {
+			t.Fatalf("ParseULID does not work as expected. ms: %d, n: %d", ms, n)
+		}
+	}
+
+	for _, s := range []string{"", "0000000000000000000000000", "8ZZZZZZZZZ00FZZZZZZZZZZZZZ", "0000000000000000000000000U", "01ARYZ6S41TSV4RRFFQ69G5FAV"} {
+		if _, _, err := ParseULID(s); err == nil {
+			t.Fatalf("ParseULID should fail. s: %q", s)
+		}
+	}
+}
+
+func TestWUID_NextULID(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Reset(1 << 36)
+	before := uint64(time.Now().UnixMilli())
+	s1, s2 := g.NextULID(), g.NextULID()
+	if len(s1) != 26 || s1 >= s2 {
+		t.Fatalf("NextULID does not work as expected: %s, %s", s1, s2)
+	}
+	ms, n, err := ParseULID(s1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms < before || ms > uint64(time.Now().UnixMilli()) || n != 1<<36+1 {
+		t.Fatalf("NextULID does not work as expected: %s", s1)
+	}
+}
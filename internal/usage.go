@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"time"
+)
+
+// Usage is for internal use only.
+type Usage struct {
+	// Tag is the tag of the WUID
+	Tag string
+	// H28 is the h28 in use when the WUID was closed, excluding the section ID
+	H28 uint64
+	// Section is the section ID, or 0 if there is none
+	Section uint8
+	// Consumed is how many numbers have been generated with the h28
+	Consumed uint64
+	// Remaining is how many numbers could still have been generated with the h28
+	Remaining uint64
+	// Spare is the h28 of the prefetched block, which has not been used at all, or 0 if there is none
+	Spare uint64
+	// ClosedAt is when the WUID was closed
+	ClosedAt time.Time
+}
+
+// usage describes the block in use when the counter stopped at x.
+func (this *WUID) usage(x, spare uint64) Usage {
+	v := x & this.lowMask
+	u := Usage{
+		Tag:      this.Tag,
+		H28:      x >> this.lowBits & this.MaxH28(),
+		Section:  this.Section,
+		Consumed: v / this.step,
+		Spare:    spare,
+		ClosedAt: time.Now(),
+	}
+	if v < this.panicValue {
+		u.Remaining = (this.panicValue - v - 1) / this.step
+	}
+	return u
+}
+
+// WithUsageReport is for internal use only.
+func WithUsageReport(report func(u Usage) error) Option {
+	return func(w *WUID) {
+		w.usageReport = report
+	}
+}
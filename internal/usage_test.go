@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithUsageReport(t *testing.T) {
+	var reports []Usage
+	g := NewWUID("default", nil, WithSection(3), WithPrefetch(), WithUsageReport(func(u Usage) error {
+		reports = append(reports, u)
+		return errors.New("bomb")
+	}))
+	g.ResetH28(42)
+	g.ResetH28(43)
+	for i := 0; i < 100; i++ {
+		g.Next()
+	}
+	if err := g.Close(); err == nil || err.Error() != "bomb" {
+		t.Fatalf("Close should return the error of the report: %v", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("the usage should be reported exactly once: %d", len(reports))
+	}
+	u := reports[0]
+	if u.Tag != "default" || u.H28 != 42 || u.Section != 3 || u.Consumed != 100 || u.Spare != 43 {
+		t.Fatalf("the usage is not reported as expected: %+v", u)
+	}
+	if u.Remaining != PanicValue-101 || u.ClosedAt.IsZero() {
+		t.Fatalf("the usage is not reported as expected: %+v", u)
+	}
+
+	g = NewWUID("default", nil, WithUsageReport(func(u Usage) error {
+		t.Fatal("nothing should be reported before the WUID is loaded")
+		return nil
+	}))
+	_ = g.Close()
+}
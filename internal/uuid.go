@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// UUID is for internal use only.
+type UUID [16]byte
+
+// NewUUID is for internal use only.
+func NewUUID(n uint64) UUID {
+	var u UUID
+	binary.BigEndian.PutUint64(u[:8], n>>16<<16|0x8<<12|n>>4&0xFFF)
+	binary.BigEndian.PutUint64(u[8:], 0x2<<62|(n&0xF)<<58)
+	return u
+}
+
+// Uint64 returns the unique number embedded in the UUID.
+func (u UUID) Uint64() (uint64, error) {
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	if hi>>12&0xF != 0x8 || lo>>62 != 0x2 || lo&(1<<58-1) != 0 {
+		return 0, errors.New("the UUID was not generated by WUID: " + u.String())
+	}
+	return hi>>16<<16 | (hi&0xFFF)<<4 | lo>>58&0xF, nil
+}
+
+// String returns the UUID in the canonical form, e.g. 000002a0-0000-8000-8400-000000000000.
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// MarshalText encodes the UUID in the canonical form.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText decodes the UUID from the canonical form.
+func (u *UUID) UnmarshalText(text []byte) error {
+	v, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// Value implements driver.Valuer. The UUID is stored in the canonical form.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// Scan implements sql.Scanner. It accepts both the canonical form and 16 raw bytes.
+func (u *UUID) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return u.UnmarshalText([]byte(v))
+	case []byte:
+		if len(v) == 16 {
+			copy(u[:], v)
+			return nil
+		}
+		return u.UnmarshalText(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a UUID", src)
+	}
+}
+
+// ParseUUID is for internal use only.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.New("invalid UUID: " + s)
+	}
+	var buf [32]byte
+	copy(buf[0:8], s[0:8])
+	copy(buf[8:12], s[9:13])
+	copy(buf[12:16], s[14:18])
+	copy(buf[16:20], s[19:23])
+	copy(buf[20:], s[24:])
+	if _, err := hex.Decode(u[:], buf[:]); err != nil {
+		return UUID{}, errors.New("invalid UUID: " + s)
+	}
+	return u, nil
+}
+
+// NextUUID is for internal use only.
+func (this *WUID) NextUUID() UUID {
+	return NewUUID(this.Next())
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestNewUUID(t *testing.T) {
+	u := NewUUID(0x000002a000000001)
+	if s := u.String(); s != "000002a0-0000-8000-8400-000000000000" {
+		t.Fatalf("NewUUID does not work as expected: %s", s)
+	}
+	if u[6]>>4 != 8 || u[8]>>6 != 2 {
+		t.Fatalf("the version and the variant are not set as expected: %s", u)
+	}
+
+	for i := 0; i < 10000; i++ {
+		n := rand.Uint64()
+		v, err := NewUUID(n).Uint64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != n {
+			t.Fatalf("UUID.Uint64 does not work as expected. n: %x, v: %x", n, v)
+		}
+		m := rand.Uint64()
+		u1, u2 := NewUUID(n), NewUUID(m)
+		if (n < m) != (bytes.Compare(u1[:], u2[:]) < 0) {
+			t.Fatalf("the UUIDs should sort in the same order as the numbers. n: %x, m: %x", n, m)
+		}
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	u := NewUUID(0x000002a000000001)
+	v, err := ParseUUID(u.String())
+	if err != nil || v != u {
+		t.Fatalf("ParseUUID does not work as expected: %s, %v", v, err)
+	}
+	var w UUID
+	if err := w.UnmarshalText([]byte("000002A0-0000-8000-8400-000000000000")); err != nil || w != u {
+		t.Fatalf("UUID.UnmarshalText does not work as expected: %s, %v", w, err)
+	}
+
+	for _, s := range []string{"", "00000000-02a0-8000-8400-00000000000", "00000000x02a0-8000-8400-000000000000", "0000000g-02a0-8000-8400-000000000000"} {
+		if _, err := ParseUUID(s); err == nil {
+			t.Fatalf("ParseUUID should fail. s: %q", s)
+		}
+	}
+	for _, s := range []string{"00000000-02a0-4000-8400-000000000000", "00000000-02a0-8000-8400-000000000001"} {
+		u, _ := ParseUUID(s)
+		if _, err := u.Uint64(); err == nil {
+			t.Fatalf("UUID.Uint64 should fail. s: %q", s)
+		}
+	}
+}
+
+func TestUUID_Scan(t *testing.T) {
+	u := NewUUID(0x000002a000000001)
+	for _, src := range []interface{}{u.String(), []byte(u.String()), u[:]} {
+		var v UUID
+		if err := v.Scan(src); err != nil || v != u {
+			t.Fatalf("UUID.Scan does not work as expected: %s, %v", v, err)
+		}
+	}
+	var v UUID
+	if err := v.Scan(nil); err == nil {
+		t.Fatal("UUID.Scan should fail when src is nil")
+	}
+	if val, err := u.Value(); err != nil || val != u.String() {
+		t.Fatalf("UUID.Value does not work as expected: %v, %v", val, err)
+	}
+}
+
+func TestWUID_NextUUID(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Reset(42 << 36)
+	n, err := g.NextUUID().Uint64()
+	if err != nil || n != 42<<36+1 {
+		t.Fatalf("NextUUID does not work as expected: %x, %v", n, err)
+	}
+}
@@ -1,11 +1,15 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"math/bits"
+	"math/rand"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -15,6 +19,46 @@ const (
 	RenewInterval uint64 = 0x3FFFFFFF
 	// PanicValue indicates when Next starts to panic
 	PanicValue uint64 = (1 << 36) * 96 / 100
+	// RenewRetryDelay indicates how long NextCtx waits before it retries a failed renew, and how long
+	// the first background retry of a failed renew is delayed at least
+	RenewRetryDelay = time.Second
+	// RenewBackoffMax is the longest delay between the retries of a failed background renew
+	RenewBackoffMax = time.Minute
+	// MaxStep is the largest step that WithStep accepts
+	MaxStep uint64 = 1 << 14
+)
+
+const (
+	// DefaultHBits is the default number of the high bits, which are loaded from a data store
+	DefaultHBits = 28
+	// DefaultLowBits is the default number of the low bits, which are incremented by Next
+	DefaultLowBits = 36
+	// JSSafeHBits is the number of the high bits in the JavaScript-safe layout
+	JSSafeHBits = 21
+	// JSSafeLowBits is the number of the low bits in the JavaScript-safe layout
+	JSSafeLowBits = 32
+	// SignedSafeHBits is the number of the high bits in the signed-safe layout
+	SignedSafeHBits = 27
+	// LayoutVersionBits is the number of the bits reserved by WithLayoutVersion
+	LayoutVersionBits = 2
+)
+
+// TimePrefixEpoch is for internal use only.
+var TimePrefixEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrExhausted is for internal use only.
+	ErrExhausted = errors.New("<wuid> the low bits have run out")
+	// ErrNotLoaded is for internal use only.
+	ErrNotLoaded = errors.New("<wuid> the high bits have not been loaded yet")
+	// ErrClosed is for internal use only.
+	ErrClosed = errors.New("<wuid> the generator has been closed")
+	// ErrRenewTimeout is for internal use only.
+	ErrRenewTimeout = errors.New("<wuid> the renew timed out")
+	// ErrBreakerOpen is for internal use only.
+	ErrBreakerOpen = errors.New("<wuid> the circuit breaker is open")
+	// ErrRenewFailing is for internal use only.
+	ErrRenewFailing = errors.New("<wuid> the low bits are running out and the renew keeps failing")
 )
 
 // WUID is for internal use only.
@@ -25,12 +69,69 @@ type WUID struct {
 	Tag         string
 	Logger      Logger
 	Renew       func() error
+	RenewCtx    func(ctx context.Context) error
+	ReserveH28  func(k uint64) (last uint64, err error)
 	H28Verifier func(h28 uint64) error
+
+	onRenew      func(oldH28, newH28 uint64)
+	onRenewError func(h28 uint64, err error)
+
+	renewing int32
+	retrying int32
+	resetMu  sync.Mutex
+	resetCh  chan struct{}
+	closed   int32
+	closers  []func() error
+	finalN   uint64
+	spare    uint64
+
+	nextBlock uint64
+	lastBlock uint64
+
+	statsMu      sync.Mutex
+	renews       uint64
+	lastRenewAt  time.Time
+	lastRenewErr error
+	failures     int
+	breakerUntil time.Time
+
+	hBits             uint8
+	lowBits           uint8
+	sectionBits       uint8
+	region            uint8
+	regionBits        uint8
+	lowMask           uint64
+	criticalValue     uint64
+	panicValue        uint64
+	renewInterval     uint64
+	renewThreshold    float64
+	renewTimeout      time.Duration
+	maxFailures       int
+	cooldown          time.Duration
+	prefetch          bool
+	blocksPerRenew    uint64
+	usageReport       func(u Usage) error
+	exhaustion        ExhaustionPolicy
+	exhaustionTimeout time.Duration
+	step              uint64
+	skip              func(n uint64) bool
+	alphabet          *Alphabet
+	obfuscator        *Obfuscator
+	prefix            string
+	checkDigit        bool
+	rotation          time.Duration
+	rotationTimer     *time.Timer
+	snowflake         *snowflake
+	timeBits          uint8
+	timeUnit          time.Duration
+	layoutVersion     uint8
+	versionBits       uint8
 }
 
 // NewWUID is for internal use only.
 func NewWUID(tag string, logger Logger, opts ...Option) *WUID {
-	w := &WUID{Tag: tag}
+	w := &WUID{Tag: tag, step: 1}
+	w.setLayout(DefaultHBits, DefaultLowBits)
 	if logger != nil {
 		w.Logger = logger
 	} else {
@@ -39,52 +140,815 @@ func NewWUID(tag string, logger Logger, opts ...Option) *WUID {
 	for _, opt := range opts {
 		opt(w)
 	}
+	if w.renewThreshold != 0 {
+		w.setRenewThreshold(w.renewThreshold)
+	}
+	if w.snowflake != nil && (w.Section != 0 || w.sectionBits != 0 || w.regionBits != 0 || w.versionBits != 0 || w.step != 1 ||
+		w.skip != nil || w.obfuscator != nil || w.hBits != DefaultHBits || w.lowBits != DefaultLowBits) {
+		panic("the Snowflake layout cannot be combined with the other layout options")
+	}
+	if w.snowflake != nil && w.timeBits != 0 {
+		panic("the Snowflake layout cannot be combined with the time prefix")
+	}
+	if w.prefetch && (w.snowflake != nil || w.rotation > 0) {
+		panic("prefetching cannot be combined with the Snowflake layout or rotation")
+	}
+	if w.obfuscator != nil && (w.hBits+w.lowBits != 64 || w.sectionBits != 0 || w.timeBits != 0 || w.versionBits != 0) {
+		panic("obfuscation works only with a 64-bit layout without a section width, a time prefix or a version")
+	}
+	if w.hBits <= w.versionBits+w.timeBits+w.sectionWidth()+w.regionBits {
+		panic("hBits must be greater than the total width of the version, the time prefix, the section ID and the region ID")
+	}
+	if width := w.sectionWidth(); width > 0 && uint64(w.Section) >= 1<<width {
+		panic("the section ID does not fit in the section width")
+	}
 	return w
 }
 
 // Next is for internal use only.
 func (this *WUID) Next() uint64 {
-	x := atomic.AddUint64(&this.N, 1)
-	v := x & 0xFFFFFFFFF
-	if v >= PanicValue {
-		atomic.StoreUint64(&this.N, 0xFFFFFFFFF)
-		panic("<wuid> the low 36 bits are about to run out")
-	}
-	if v >= CriticalValue && v&RenewInterval == 0 {
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					this.Logger.Warn(fmt.Sprintf("<wuid> panic, renew failed. tag: %s, reason: %+v", this.Tag, r))
-				}
-			}()
-
-			err := this.RenewNow()
-			if err != nil {
-				this.Logger.Warn(fmt.Sprintf("<wuid> renew failed. tag: %s, reason: %+v", this.Tag, err))
-			} else {
-				this.Logger.Info(fmt.Sprintf("<wuid> renew succeeded. tag: %s", this.Tag))
+	if this.snowflake != nil {
+		return this.nextSnowflake()
+	}
+	x := atomic.AddUint64(&this.N, this.step)
+	v := x & this.lowMask
+	if v >= this.panicValue {
+		if this.useSpare(x) {
+			return this.Next()
+		}
+		if this.exhaustion == ExhaustionBlock {
+			this.clamp(x, this.step)
+			return this.nextBlocking()
+		}
+		this.exhausted(x, this.step)
+	}
+	if v >= this.criticalValue && this.crossed(v, this.step) {
+		go this.renew()
+	}
+	x = this.present(x)
+	if this.skip != nil && this.skip(x) {
+		return this.Next()
+	}
+	return x
+}
+
+// NextE is for internal use only.
+func (this *WUID) NextE() (uint64, error) {
+	for {
+		if atomic.LoadInt32(&this.closed) != 0 {
+			return 0, fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+		}
+		if this.H28() == 0 {
+			return 0, fmt.Errorf("%w. tag: %s", ErrNotLoaded, this.Tag)
+		}
+		if this.snowflake != nil {
+			return compose(this.snowflake.next(), this.H28()), nil
+		}
+		x := atomic.AddUint64(&this.N, this.step)
+		v := x & this.lowMask
+		if v >= this.panicValue {
+			if this.useSpare(x) {
+				continue
 			}
-		}()
+			this.clamp(x, this.step)
+			this.Lock()
+			renew := this.Renew
+			this.Unlock()
+			if renew != nil {
+				this.renewOnce()
+			}
+			return 0, fmt.Errorf("%w. tag: %s", ErrExhausted, this.Tag)
+		}
+		if v >= this.criticalValue && this.crossed(v, this.step) {
+			go this.renew()
+		}
+		x = this.present(x)
+		if this.skip != nil && this.skip(x) {
+			continue
+		}
+		return x, nil
+	}
+}
+
+// NextN is for internal use only.
+func (this *WUID) NextN(n int) []uint64 {
+	if n <= 0 {
+		return nil
+	}
+	return this.AppendNextN(make([]uint64, 0, n), n)
+}
+
+// AppendNextN is for internal use only.
+func (this *WUID) AppendNextN(dst []uint64, n int) []uint64 {
+	if n <= 0 {
+		return dst
+	}
+	if this.snowflake != nil {
+		for i := 0; i < n; i++ {
+			dst = append(dst, this.nextSnowflake())
+		}
+		return dst
+	}
+	delta := uint64(n) * this.step
+	x := atomic.AddUint64(&this.N, delta)
+	v := x & this.lowMask
+	if v >= this.panicValue || v < delta {
+		if this.useSpare(x) {
+			return this.AppendNextN(dst, n)
+		}
+		if this.exhaustion == ExhaustionBlock {
+			this.clamp(x, delta)
+			for i := 0; i < n; i++ {
+				dst = append(dst, this.nextBlocking())
+			}
+			return dst
+		}
+		this.exhausted(x, delta)
+	}
+	if v >= this.criticalValue && this.crossed(v, delta) {
+		go this.renew()
+	}
+	start := len(dst)
+	for next := x - delta + this.step; next <= x; next += this.step {
+		dst = append(dst, this.present(next))
+	}
+	if this.skip != nil {
+		dst = this.dropSkipped(dst, start, n)
+	}
+	return dst
+}
+
+// exhausted panics because the low bits have run out or the generator has been closed, after
+// clamping N with clamp(x, delta). With ExhaustionError, it panics with an error wrapping
+// ErrExhausted or ErrClosed instead of a string.
+func (this *WUID) exhausted(x, delta uint64) {
+	this.clamp(x, delta)
+	if atomic.LoadInt32(&this.closed) != 0 {
+		if this.exhaustion == ExhaustionError {
+			panic(fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag))
+		}
+		panic("<wuid> the generator has been closed. tag: " + this.Tag)
+	}
+	if this.exhaustion == ExhaustionError {
+		this.Lock()
+		renew := this.Renew
+		this.Unlock()
+		if renew != nil {
+			this.renewOnce()
+		}
+		panic(fmt.Errorf("%w. tag: %s", ErrExhausted, this.Tag))
+	}
+	panic(fmt.Sprintf("<wuid> the low %d bits are about to run out", this.lowBits))
+}
+
+// clamp moves N back to the panic value of the block of x-delta, i.e. the value N had before
+// the addition of delta returned x, unless another caller has moved N since then. Even if the
+// addition carried the low bits into the next h28, the h28 of the loaded block is kept, and no
+// number of that never loaded block is handed out.
+func (this *WUID) clamp(x, delta uint64) {
+	atomic.CompareAndSwapUint64(&this.N, x, (x-delta)&^this.lowMask|this.panicValue)
+}
+
+// nextBlocking waits for a renew like NextCtx, and panics with an error wrapping ErrExhausted if
+// none succeeds within the exhaustion timeout.
+func (this *WUID) nextBlocking() uint64 {
+	ctx, cancel := context.WithTimeout(context.Background(), this.exhaustionTimeout)
+	defer cancel()
+	x, err := this.NextCtx(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w. tag: %s", ErrExhausted, this.Tag)
+	}
+	if err != nil {
+		panic(err)
 	}
 	return x
 }
 
+// dropSkipped removes the numbers that should be skipped from dst[start:], and then tops it up
+// to n numbers with Next.
+func (this *WUID) dropSkipped(dst []uint64, start, n int) []uint64 {
+	k := start
+	for _, x := range dst[start:] {
+		if !this.skip(x) {
+			dst[k] = x
+			k++
+		}
+	}
+	dst = dst[:k]
+	for len(dst)-start < n {
+		dst = append(dst, this.Next())
+	}
+	return dst
+}
+
+// NextCtx is for internal use only.
+func (this *WUID) NextCtx(ctx context.Context) (uint64, error) {
+	if this.snowflake != nil {
+		if atomic.LoadInt32(&this.closed) != 0 {
+			return 0, fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+		}
+		return compose(this.snowflake.next(), this.H28()), nil
+	}
+	for {
+		ch := this.resetChan()
+		// The callers waiting for a renew do not add to N, otherwise they would carry the low
+		// bits into the next h28 sooner or later.
+		x := atomic.LoadUint64(&this.N)
+		exhausted := x&this.lowMask >= this.panicValue
+		if !exhausted {
+			x = atomic.AddUint64(&this.N, this.step)
+		}
+		v := x & this.lowMask
+		if v < this.panicValue {
+			if v >= this.criticalValue && this.crossed(v, this.step) {
+				go this.renew()
+			}
+			x = this.present(x)
+			if this.skip != nil && this.skip(x) {
+				continue
+			}
+			return x, nil
+		}
+
+		if !exhausted {
+			this.clamp(x, this.step)
+		}
+		if atomic.LoadInt32(&this.closed) != 0 {
+			return 0, fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+		}
+		if this.useSpare(x) {
+			continue
+		}
+
+		this.Lock()
+		renew := this.Renew
+		this.Unlock()
+		if renew == nil {
+			return 0, fmt.Errorf("<wuid> the low %d bits have run out, and there is nothing to renew with. tag: %s", this.lowBits, this.Tag)
+		}
+		this.renewOnce()
+
+		timer := time.NewTimer(RenewRetryDelay)
+		select {
+		case <-ch:
+			timer.Stop()
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// Peek is for internal use only.
+func (this *WUID) Peek() uint64 {
+	if this.snowflake != nil {
+		return compose(this.snowflake.following(atomic.LoadUint64(&this.snowflake.state)), this.H28())
+	}
+	x := atomic.LoadUint64(&this.N) + this.step
+	for this.skip != nil && this.skip(this.present(x)) {
+		x += this.step
+	}
+	return this.present(x)
+}
+
+// LastIssued is for internal use only.
+func (this *WUID) LastIssued() uint64 {
+	if this.snowflake != nil {
+		if state := atomic.LoadUint64(&this.snowflake.state); state != 0 {
+			return compose(state, this.H28())
+		}
+		return 0
+	}
+	x := atomic.LoadUint64(&this.N)
+	if x&this.lowMask == 0 {
+		return 0
+	}
+	return this.present(x)
+}
+
+// present stamps x with the time prefix and passes it through the obfuscator, if there are any.
+func (this *WUID) present(x uint64) uint64 {
+	if this.timeBits != 0 {
+		x |= this.timeBucket(time.Now()) << this.timeShift()
+	}
+	if this.obfuscator == nil {
+		return x
+	}
+	return this.obfuscator.Obfuscate(x)
+}
+
+// timeShift returns the position of the lowest bit of the time prefix.
+func (this *WUID) timeShift() uint8 {
+	return this.hBits + this.lowBits - this.versionBits - this.timeBits
+}
+
+// timeBucket returns how many time units have passed since TimePrefixEpoch at t, wrapping around
+// within the time prefix.
+func (this *WUID) timeBucket(t time.Time) uint64 {
+	d := t.Sub(TimePrefixEpoch)
+	if d < 0 {
+		return 0
+	}
+	return uint64(d/this.timeUnit) & (1<<this.timeBits - 1)
+}
+
+// crossed reports whether the low bits have just crossed a renew interval boundary by adding
+// delta to reach v.
+func (this *WUID) crossed(v, delta uint64) bool {
+	return (v-delta)&^this.renewInterval != v&^this.renewInterval
+}
+
+// resetChan returns a channel that is closed by the next call to Reset.
+func (this *WUID) resetChan() <-chan struct{} {
+	this.resetMu.Lock()
+	defer this.resetMu.Unlock()
+	if this.resetCh == nil {
+		this.resetCh = make(chan struct{})
+	}
+	return this.resetCh
+}
+
+// renewOnce starts a renew in the background unless there is one in progress already.
+func (this *WUID) renewOnce() {
+	if !atomic.CompareAndSwapInt32(&this.renewing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&this.renewing, 0)
+		this.renew()
+	}()
+}
+
+func (this *WUID) renew() {
+	defer func() {
+		if r := recover(); r != nil {
+			this.Logger.Warn(fmt.Sprintf("<wuid> panic, renew failed. tag: %s, reason: %+v", this.Tag, r))
+		}
+	}()
+
+	if this.prefetch && this.spareH28() != 0 {
+		return
+	}
+
+	h28 := this.H28()
+	err := this.RenewNow()
+	if err != nil {
+		this.Logger.Warn(fmt.Sprintf("<wuid> renew failed. tag: %s, reason: %+v", this.Tag, err))
+		this.retryRenew(h28)
+	} else {
+		this.Logger.Info(fmt.Sprintf("<wuid> renew succeeded. tag: %s", this.Tag))
+	}
+}
+
+// retryRenew keeps retrying the renew in the background with exponential backoff and jitter,
+// until it succeeds, the current h28 differs from h28, or the generator is closed. There is at
+// most one such goroutine at a time.
+func (this *WUID) retryRenew(h28 uint64) {
+	if !atomic.CompareAndSwapInt32(&this.retrying, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&this.retrying, 0)
+		delay := RenewRetryDelay
+		for attempt := 1; ; attempt++ {
+			time.Sleep(delay + time.Duration(rand.Int63n(int64(delay/2))))
+			if atomic.LoadInt32(&this.closed) != 0 || this.H28() != h28 {
+				return
+			}
+			if err := this.tryRenew(); err != nil {
+				this.Logger.Warn(fmt.Sprintf("<wuid> renew retry #%d failed. tag: %s, reason: %+v", attempt, this.Tag, err))
+			} else {
+				this.Logger.Info(fmt.Sprintf("<wuid> renew retry #%d succeeded. tag: %s", attempt, this.Tag))
+				return
+			}
+			delay = min(delay*2, RenewBackoffMax)
+		}
+	}()
+}
+
+// tryRenew calls RenewNow and turns a panic into an error.
+func (this *WUID) tryRenew() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %+v", r)
+		}
+	}()
+	return this.RenewNow()
+}
+
 // RenewNow reacquires the high 28 bits from your data store immediately
 func (this *WUID) RenewNow() error {
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+	}
+
 	this.Lock()
-	renew := this.Renew
+	renew, renewCtx, reserve := this.Renew, this.RenewCtx, this.ReserveH28
+	if this.blocksPerRenew > 1 && reserve != nil {
+		renew = func() error {
+			return this.renewFromBlocks(reserve)
+		}
+		renewCtx = nil
+	}
 	this.Unlock()
 
-	return renew()
+	if this.blocksPerRenew > 1 && reserve == nil {
+		return errors.New("the loader cannot reserve h28 values in bulk, so it does not work with WithBlocksPerRenew. tag: " + this.Tag)
+	}
+
+	if !this.allowRenew() {
+		return fmt.Errorf("%w. tag: %s", ErrBreakerOpen, this.Tag)
+	}
+
+	h28 := this.H28()
+	var err error
+	switch {
+	case this.renewTimeout <= 0:
+		err = renew()
+	case renewCtx != nil:
+		ctx, cancel := context.WithTimeout(context.Background(), this.renewTimeout)
+		err = renewCtx(ctx)
+		// A context derived from ctx may expire a moment before ctx itself, so check the deadline
+		// rather than ctx.Err().
+		if deadline, _ := ctx.Deadline(); err != nil && !time.Now().Before(deadline) {
+			err = fmt.Errorf("%w. tag: %s, reason: %v", ErrRenewTimeout, this.Tag, err)
+		}
+		cancel()
+	default:
+		err = this.renewWithTimeout(renew)
+	}
+	this.statsMu.Lock()
+	if err == nil {
+		this.renews++
+		this.failures = 0
+	} else {
+		this.failures++
+	}
+	this.lastRenewAt = time.Now()
+	this.lastRenewErr = err
+	if this.breakerOpen() {
+		this.breakerUntil = this.lastRenewAt.Add(this.cooldown)
+	}
+	this.statsMu.Unlock()
+
+	if err == nil {
+		if this.onRenew != nil {
+			this.onRenew(h28, this.H28())
+		}
+	} else {
+		if this.onRenewError != nil {
+			this.onRenewError(h28, err)
+		}
+	}
+	return err
+}
+
+// allowRenew reports whether the circuit breaker lets a renew through. Once the cool-down is over,
+// it lets one renew through per cool-down to probe the data store, until one succeeds.
+func (this *WUID) allowRenew() bool {
+	this.statsMu.Lock()
+	defer this.statsMu.Unlock()
+	if !this.breakerOpen() {
+		return true
+	}
+	now := time.Now()
+	if now.Before(this.breakerUntil) {
+		return false
+	}
+	this.breakerUntil = now.Add(this.cooldown)
+	return true
+}
+
+// breakerOpen must be called with statsMu held.
+func (this *WUID) breakerOpen() bool {
+	return this.maxFailures > 0 && this.failures >= this.maxFailures
+}
+
+// spareH28 returns the h28 of the prefetched block, or 0 if there is none.
+func (this *WUID) spareH28() uint64 {
+	this.resetMu.Lock()
+	defer this.resetMu.Unlock()
+	return this.spare
+}
+
+// renewWithTimeout calls renew on another goroutine and gives up waiting for it after the renew
+// timeout. The abandoned call keeps running until the data store responds, but it no longer holds
+// up the caller.
+func (this *WUID) renewWithTimeout(renew func() error) error {
+	ch := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- fmt.Errorf("panic: %+v", r)
+			}
+		}()
+		ch <- renew()
+	}()
+
+	timer := time.NewTimer(this.renewTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-ch:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w. tag: %s", ErrRenewTimeout, this.Tag)
+	}
+}
+
+// Stats is for internal use only.
+type Stats struct {
+	// H28 is the current high 28 bits, excluding the section ID
+	H28 uint64
+	// Section is the section ID, or 0 if there is none
+	Section uint8
+	// Consumed is how many numbers have been generated with the current h28
+	Consumed uint64
+	// Remaining is how many numbers can still be generated before Next starts to panic
+	Remaining uint64
+	// Renews is how many renews have succeeded
+	Renews uint64
+	// LastRenewAt is when the last renew was performed, or the zero time if there is none
+	LastRenewAt time.Time
+	// LastRenewErr is the error of the last renew, or nil if it succeeded
+	LastRenewErr error
+	// Spare is the h28 of the prefetched block, or 0 if there is none. See WithPrefetch.
+	Spare uint64
+	// Failures is how many renews have failed in a row
+	Failures int
+	// BreakerOpen tells whether the circuit breaker is open, i.e. whether the renews are rejected
+	// without calling the data store, except for one probe per cool-down
+	BreakerOpen bool
+}
+
+// Stats is for internal use only.
+func (this *WUID) Stats() Stats {
+	x := atomic.LoadUint64(&this.N)
+	v := x & this.lowMask
+	stats := Stats{
+		H28:      x >> this.lowBits & this.MaxH28(),
+		Section:  this.Section,
+		Consumed: v / this.step,
+		Spare:    this.spareH28(),
+	}
+	if v < this.panicValue {
+		stats.Remaining = (this.panicValue - v - 1) / this.step
+	}
+	this.statsMu.Lock()
+	stats.Renews = this.renews
+	stats.LastRenewAt = this.lastRenewAt
+	stats.LastRenewErr = this.lastRenewErr
+	stats.Failures = this.failures
+	stats.BreakerOpen = this.breakerOpen()
+	this.statsMu.Unlock()
+	return stats
+}
+
+// Healthy is for internal use only.
+func (this *WUID) Healthy() error {
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+	}
+	if this.snowflake != nil {
+		return nil
+	}
+	x := atomic.LoadUint64(&this.N)
+	if x>>this.lowBits&this.MaxH28() == 0 {
+		return fmt.Errorf("%w. tag: %s", ErrNotLoaded, this.Tag)
+	}
+
+	this.statsMu.Lock()
+	failures, lastErr, breakerOpen := this.failures, this.lastRenewErr, this.breakerOpen()
+	this.statsMu.Unlock()
+	if breakerOpen {
+		return fmt.Errorf("%w. tag: %s, reason: %v", ErrBreakerOpen, this.Tag, lastErr)
+	}
+
+	// With a prefetched block, the generator switches to it instantly, however far the current
+	// one has gone.
+	if this.spareH28() != 0 {
+		return nil
+	}
+	v := x & this.lowMask
+	if v >= this.panicValue {
+		return fmt.Errorf("%w. tag: %s", ErrExhausted, this.Tag)
+	}
+	if v >= this.criticalValue && failures > 0 {
+		return fmt.Errorf("%w. tag: %s, reason: %v", ErrRenewFailing, this.Tag, lastErr)
+	}
+	return nil
 }
 
 // Reset is for internal use only.
 func (this *WUID) Reset(n uint64) {
-	if this.Section == 0 {
-		atomic.StoreUint64(&this.N, n)
-	} else {
-		atomic.StoreUint64(&this.N, n&0x0FFFFFFFFFFFFFFF|uint64(this.Section)<<60)
+	this.resetMu.Lock()
+	defer this.resetMu.Unlock()
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return
+	}
+	this.reset(n)
+}
+
+// reset must be called with resetMu held.
+func (this *WUID) reset(n uint64) {
+	atomic.StoreUint64(&this.N, this.stamp(n))
+
+	if this.resetCh != nil {
+		close(this.resetCh)
+		this.resetCh = nil
+	}
+	if this.rotation > 0 && this.rotationTimer == nil {
+		this.rotationTimer = time.AfterFunc(this.untilRotation(), this.rotate)
+	}
+}
+
+// stamp replaces the bits of n above the h28 with the fixed fields, i.e. the layout version, the
+// section ID and the region ID, leaving the bits of the time prefix 0.
+func (this *WUID) stamp(n uint64) uint64 {
+	width := this.versionBits + this.timeBits + this.sectionWidth() + this.regionBits
+	if width == 0 {
+		return n
 	}
+	shift := this.hBits + this.lowBits - width
+	fixed := uint64(this.layoutVersion)<<(this.timeBits+this.sectionWidth()+this.regionBits) |
+		uint64(this.Section)<<this.regionBits | uint64(this.region)
+	return n&(1<<shift-1) | fixed<<shift
+}
+
+// untilRotation returns how long it is until the next multiple of the rotation interval since
+// the zero time, e.g. the next midnight UTC if the interval is 24 hours.
+func (this *WUID) untilRotation() time.Duration {
+	now := time.Now()
+	return now.Truncate(this.rotation).Add(this.rotation).Sub(now)
+}
+
+// rotate starts a renew and schedules the next one.
+func (this *WUID) rotate() {
+	this.resetMu.Lock()
+	if atomic.LoadInt32(&this.closed) != 0 {
+		this.resetMu.Unlock()
+		return
+	}
+	this.rotationTimer.Reset(this.untilRotation())
+	this.resetMu.Unlock()
+
+	this.Lock()
+	renew := this.Renew
+	this.Unlock()
+	if renew != nil {
+		this.renewOnce()
+	}
+}
+
+// AddCloser is for internal use only.
+func (this *WUID) AddCloser(fn func() error) {
+	this.Lock()
+	defer this.Unlock()
+	this.closers = append(this.closers, fn)
+}
+
+// Close is for internal use only.
+func (this *WUID) Close() error {
+	this.resetMu.Lock()
+	if !atomic.CompareAndSwapInt32(&this.closed, 0, 1) {
+		this.resetMu.Unlock()
+		return nil
+	}
+	x := atomic.LoadUint64(&this.N)
+	atomic.StoreUint64(&this.finalN, x)
+	atomic.StoreUint64(&this.N, x&^this.lowMask|this.panicValue)
+	spare := this.spare
+	this.spare = 0
+	if this.resetCh != nil {
+		close(this.resetCh)
+		this.resetCh = nil
+	}
+	if this.rotationTimer != nil {
+		this.rotationTimer.Stop()
+	}
+	this.resetMu.Unlock()
+
+	this.Lock()
+	this.Renew = func() error {
+		return fmt.Errorf("%w. tag: %s", ErrClosed, this.Tag)
+	}
+	this.RenewCtx = nil
+	this.ReserveH28 = nil
+	closers := this.closers
+	this.closers = nil
+	this.Unlock()
+
+	// Report before the closers release the resources the report may need.
+	var errs []error
+	if this.usageReport != nil && x>>this.lowBits&this.MaxH28() != 0 && this.snowflake == nil {
+		if err := this.usageReport(this.usage(x, spare)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ResetH28 is for internal use only.
+func (this *WUID) ResetH28(h28 uint64) {
+	this.resetMu.Lock()
+	defer this.resetMu.Unlock()
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return
+	}
+	if this.prefetch && this.spare == 0 && this.H28() != 0 && atomic.LoadUint64(&this.N)&this.lowMask < this.panicValue {
+		this.spare = h28
+		return
+	}
+	this.reset(h28 << this.lowBits)
+}
+
+// useSpare switches to the prefetched block once x has run out of the current one, and starts
+// refilling the spare in the background. It reports whether the block has been switched, either
+// by this call or by a concurrent one.
+func (this *WUID) useSpare(x uint64) bool {
+	if !this.prefetch {
+		return false
+	}
+	this.resetMu.Lock()
+	defer this.resetMu.Unlock()
+	if atomic.LoadInt32(&this.closed) != 0 {
+		return false
+	}
+	if n := atomic.LoadUint64(&this.N); n>>this.lowBits != x>>this.lowBits && n&this.lowMask < this.panicValue {
+		return true
+	}
+	if this.spare == 0 {
+		return false
+	}
+	this.reset(this.spare << this.lowBits)
+	this.spare = 0
+	this.renewOnce()
+	return true
+}
+
+// RenewTimeout is for internal use only.
+func (this *WUID) RenewTimeout() time.Duration {
+	return this.renewTimeout
+}
+
+// WithDeadlineOf is for internal use only.
+func WithDeadlineOf(ctx, rctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := rctx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Prefetch is for internal use only.
+func (this *WUID) Prefetch() bool {
+	return this.prefetch
+}
+
+// BlocksPerRenew is for internal use only.
+func (this *WUID) BlocksPerRenew() int {
+	return int(this.blocksPerRenew)
+}
+
+// Closed is for internal use only.
+func (this *WUID) Closed() bool {
+	return atomic.LoadInt32(&this.closed) != 0
+}
+
+// H28 is for internal use only.
+func (this *WUID) H28() uint64 {
+	return atomic.LoadUint64(&this.N) >> this.lowBits & this.MaxH28()
+}
+
+// sectionWidth returns how many of the highest bits are occupied by the section ID.
+func (this *WUID) sectionWidth() uint8 {
+	if this.sectionBits != 0 {
+		return this.sectionBits
+	}
+	if this.Section != 0 {
+		return 4
+	}
+	return 0
+}
+
+// NextWithSection is for internal use only.
+func (this *WUID) NextWithSection(section uint8) uint64 {
+	width := this.sectionWidth()
+	if this.sectionBits == 0 || uint64(section) >= 1<<width {
+		panic(fmt.Sprintf("<wuid> the section ID %d does not fit in the section width. tag: %s", section, this.Tag))
+	}
+	shift := this.hBits + this.lowBits - this.versionBits - this.timeBits - width
+	x := this.Next()
+	return x&^((1<<width-1)<<shift) | uint64(section)<<shift
+}
+
+// MaxH28 is for internal use only.
+func (this *WUID) MaxH28() uint64 {
+	return 1<<(this.hBits-this.versionBits-this.timeBits-this.sectionWidth()-this.regionBits) - 1
 }
 
 // VerifyH28 is for internal use only.
@@ -93,14 +957,8 @@ func (this *WUID) VerifyH28(h28 uint64) error {
 		return errors.New("the h28 should not be 0. tag: " + this.Tag)
 	}
 
-	if this.Section == 0 {
-		if h28 > 0x0FFFFFFF {
-			return errors.New("the h28 should not exceed 0x0FFFFFFF. tag: " + this.Tag)
-		}
-	} else {
-		if h28 > 0x00FFFFFF {
-			return errors.New("the h28 should not exceed 0x00FFFFFF. tag: " + this.Tag)
-		}
+	if max := this.MaxH28(); h28 > max {
+		return fmt.Errorf("the h28 should not exceed 0x%0*X. tag: %s", (this.hBits+3)/4+1, max, this.Tag)
 	}
 
 	if this.H28Verifier != nil {
@@ -112,6 +970,28 @@ func (this *WUID) VerifyH28(h28 uint64) error {
 	return nil
 }
 
+func (this *WUID) setLayout(hBits, lowBits uint8) {
+	this.hBits = hBits
+	this.lowBits = lowBits
+	this.lowMask = 1<<lowBits - 1
+	this.criticalValue = (1 << lowBits) * 80 / 100
+	this.panicValue = (1 << lowBits) * 96 / 100
+	this.renewInterval = 1<<(lowBits-6) - 1
+}
+
+// setRenewThreshold moves the critical value to the specified fraction of the low bits. The panic
+// value is left alone, unless the critical value is above the default, in which case it is moved
+// to 4/5 of the way from there to the end, so that the block runs out no earlier than by default.
+// The renew interval shrinks so that there are at least 8 renew retries in between, which keeps
+// the default values for 0.8.
+func (this *WUID) setRenewThreshold(fraction float64) {
+	size := uint64(1) << this.lowBits
+	this.criticalValue = uint64(float64(size) * fraction)
+	this.panicValue = max(this.panicValue, this.criticalValue+(size-this.criticalValue)*4/5)
+	gap := (this.panicValue - this.criticalValue) / 8
+	this.renewInterval = min(uint64(1)<<(this.lowBits-6), uint64(1)<<(bits.Len64(gap)-1)) - 1
+}
+
 // Logger is for internal use only.
 type Logger interface {
 	Info(args ...interface{})
@@ -141,6 +1021,280 @@ func WithSection(section uint8) Option {
 	}
 }
 
+// WithBitLayout is for internal use only.
+func WithBitLayout(hBits, lowBits uint8) Option {
+	if lowBits < 20 || lowBits > 56 {
+		panic("lowBits must be in between [20, 56]")
+	}
+	if hBits < 8 {
+		panic("hBits must not be less than 8")
+	}
+	if int(hBits)+int(lowBits) > 64 {
+		panic("hBits + lowBits must not exceed 64")
+	}
+	return func(w *WUID) {
+		w.setLayout(hBits, lowBits)
+	}
+}
+
+// WithJSSafe is for internal use only.
+func WithJSSafe() Option {
+	return WithBitLayout(JSSafeHBits, JSSafeLowBits)
+}
+
+// WithSignedSafe is for internal use only.
+func WithSignedSafe() Option {
+	return WithBitLayout(SignedSafeHBits, DefaultLowBits)
+}
+
+// WithRenewThreshold is for internal use only.
+func WithRenewThreshold(fraction float64) Option {
+	if !(fraction >= 0.01 && fraction <= 0.99) {
+		panic("fraction must be in between [0.01, 0.99]")
+	}
+	return func(w *WUID) {
+		w.renewThreshold = fraction
+	}
+}
+
+// WithRenewTimeout is for internal use only.
+func WithRenewTimeout(d time.Duration) Option {
+	if d <= 0 {
+		panic("the renew timeout must be positive")
+	}
+	return func(w *WUID) {
+		w.renewTimeout = d
+	}
+}
+
+// WithCircuitBreaker is for internal use only.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	if failures < 1 {
+		panic("failures must be positive")
+	}
+	if cooldown <= 0 {
+		panic("the cool-down must be positive")
+	}
+	return func(w *WUID) {
+		w.maxFailures = failures
+		w.cooldown = cooldown
+	}
+}
+
+// WithPrefetch is for internal use only.
+func WithPrefetch() Option {
+	return func(w *WUID) {
+		w.prefetch = true
+	}
+}
+
+// WithBlocksPerRenew is for internal use only.
+func WithBlocksPerRenew(n int) Option {
+	if n < 1 {
+		panic("n must be positive")
+	}
+	return func(w *WUID) {
+		w.blocksPerRenew = uint64(n)
+	}
+}
+
+// ExhaustionPolicy is for internal use only.
+type ExhaustionPolicy uint8
+
+const (
+	// ExhaustionPanic is for internal use only.
+	ExhaustionPanic ExhaustionPolicy = iota
+	// ExhaustionError is for internal use only.
+	ExhaustionError
+	// ExhaustionBlock is for internal use only.
+	ExhaustionBlock
+)
+
+// WithExhaustionPolicy is for internal use only.
+func WithExhaustionPolicy(policy ExhaustionPolicy, timeout time.Duration) Option {
+	if policy > ExhaustionBlock {
+		panic("unknown exhaustion policy")
+	}
+	if policy == ExhaustionBlock && timeout <= 0 {
+		panic("the timeout must be positive with ExhaustionBlock")
+	}
+	return func(w *WUID) {
+		w.exhaustion = policy
+		w.exhaustionTimeout = timeout
+	}
+}
+
+// WithStep is for internal use only.
+func WithStep(step uint64) Option {
+	if step < 1 || step > MaxStep {
+		panic(fmt.Sprintf("step must be in between [1, %d]", MaxStep))
+	}
+	return func(w *WUID) {
+		w.step = step
+	}
+}
+
+// WithSkipFunc is for internal use only.
+func WithSkipFunc(skip func(n uint64) bool) Option {
+	return func(w *WUID) {
+		if skip == nil {
+			return
+		}
+		if prev := w.skip; prev != nil {
+			w.skip = func(n uint64) bool {
+				return prev(n) || skip(n)
+			}
+		} else {
+			w.skip = skip
+		}
+	}
+}
+
+// WithSkipValues is for internal use only.
+func WithSkipValues(values ...uint64) Option {
+	m := make(map[uint64]struct{}, len(values))
+	for _, v := range values {
+		m[v] = struct{}{}
+	}
+	return WithSkipFunc(func(n uint64) bool {
+		_, ok := m[n]
+		return ok
+	})
+}
+
+// WithSkipAllOnes is for internal use only.
+func WithSkipAllOnes(bits uint8) Option {
+	if bits < 1 || bits > 64 {
+		panic("bits must be in between [1, 64]")
+	}
+	mask := ^uint64(0) >> (64 - bits)
+	return WithSkipFunc(func(n uint64) bool {
+		return n&mask == mask
+	})
+}
+
+// WithSectionWidth is for internal use only.
+func WithSectionWidth(bits uint8) Option {
+	if bits < 1 || bits > 8 {
+		panic("bits must be in between [1, 8]")
+	}
+	return func(w *WUID) {
+		w.sectionBits = bits
+	}
+}
+
+// WithRegion is for internal use only.
+func WithRegion(id uint8, bits uint8) Option {
+	if bits < 1 || bits > 8 {
+		panic("bits must be in between [1, 8]")
+	}
+	if uint64(id) >= 1<<bits {
+		panic("the region ID does not fit in the region width")
+	}
+	return func(w *WUID) {
+		w.region = id
+		w.regionBits = bits
+	}
+}
+
+// WithTimePrefix is for internal use only.
+func WithTimePrefix(bits uint8, unit time.Duration) Option {
+	if bits < 1 || bits > 40 {
+		panic("bits must be in between [1, 40]")
+	}
+	if unit < time.Millisecond {
+		panic("unit must not be less than 1 millisecond")
+	}
+	return func(w *WUID) {
+		w.timeBits = bits
+		w.timeUnit = unit
+	}
+}
+
+// WithLayoutVersion is for internal use only.
+func WithLayoutVersion(version uint8) Option {
+	if version > 1<<LayoutVersionBits-1 {
+		panic(fmt.Sprintf("version must be in between [0, %d]", 1<<LayoutVersionBits-1))
+	}
+	return func(w *WUID) {
+		w.layoutVersion = version
+		w.versionBits = LayoutVersionBits
+	}
+}
+
+// WithSnowflake is for internal use only.
+func WithSnowflake(epoch time.Time) Option {
+	if epoch.IsZero() {
+		epoch = SnowflakeEpoch
+	}
+	return func(w *WUID) {
+		w.snowflake = &snowflake{epoch: epoch.UnixMilli()}
+	}
+}
+
+// WithAlphabet is for internal use only.
+func WithAlphabet(chars string) Option {
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
+	}
+	return func(w *WUID) {
+		w.alphabet = a
+	}
+}
+
+// WithRotation is for internal use only.
+func WithRotation(interval time.Duration) Option {
+	if interval < time.Second {
+		panic("interval must not be less than 1 second")
+	}
+	return func(w *WUID) {
+		w.rotation = interval
+	}
+}
+
+// WithPrefix is for internal use only.
+func WithPrefix(prefix string) Option {
+	if err := ValidatePrefix(prefix); err != nil {
+		panic(err)
+	}
+	return func(w *WUID) {
+		w.prefix = prefix
+	}
+}
+
+// WithCheckDigit is for internal use only.
+func WithCheckDigit() Option {
+	return func(w *WUID) {
+		w.checkDigit = true
+	}
+}
+
+// WithObfuscation is for internal use only.
+func WithObfuscation(key []byte) Option {
+	o, err := NewObfuscator(key)
+	if err != nil {
+		panic(err)
+	}
+	return func(w *WUID) {
+		w.obfuscator = o
+	}
+}
+
+// WithOnRenew is for internal use only.
+func WithOnRenew(cb func(oldH28, newH28 uint64)) Option {
+	return func(w *WUID) {
+		w.onRenew = cb
+	}
+}
+
+// WithOnRenewError is for internal use only.
+func WithOnRenewError(cb func(h28 uint64, err error)) Option {
+	return func(w *WUID) {
+		w.onRenewError = cb
+	}
+}
+
 // WithH28Verifier is for internal use only.
 func WithH28Verifier(cb func(h28 uint64) error) Option {
 	return func(w *WUID) {
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync/atomic"
+)
+
+// WUID128 is for internal use only.
+type WUID128 struct {
+	lo uint64
+	hi uint64
+}
+
+// NewWUID128 is for internal use only.
+func NewWUID128(w *WUID) (*WUID128, error) {
+	if w == nil {
+		return nil, errors.New("w cannot be nil")
+	}
+	if w.H28() == 0 {
+		return nil, errors.New("the high bits have not been loaded yet. tag: " + w.Tag)
+	}
+	return &WUID128{hi: w.Next()}, nil
+}
+
+// Next is for internal use only.
+func (this *WUID128) Next() ID128 {
+	lo := atomic.AddUint64(&this.lo, 1)
+	if lo == 0 {
+		atomic.StoreUint64(&this.lo, ^uint64(0))
+		panic("<wuid> the low 64 bits have run out")
+	}
+	return ID128{Hi: this.hi, Lo: lo}
+}
+
+// ID128 is for internal use only.
+type ID128 struct {
+	Hi uint64
+	Lo uint64
+}
+
+// ID128FromBytes is for internal use only.
+func ID128FromBytes(b [16]byte) ID128 {
+	return ID128{
+		Hi: binary.BigEndian.Uint64(b[:8]),
+		Lo: binary.BigEndian.Uint64(b[8:]),
+	}
+}
+
+// Bytes returns the ID in 16 bytes in big-endian order.
+func (id ID128) Bytes() [16]byte {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], id.Hi)
+	binary.BigEndian.PutUint64(b[8:], id.Lo)
+	return b
+}
+
+// String returns the ID in 32 hexadecimal digits.
+func (id ID128) String() string {
+	b := id.Bytes()
+	return hex.EncodeToString(b[:])
+}
+
+// MarshalText encodes the ID in 32 hexadecimal digits.
+func (id ID128) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText decodes the ID from 32 hexadecimal digits.
+func (id *ID128) UnmarshalText(text []byte) error {
+	var b [16]byte
+	if len(text) != 32 {
+		return errors.New("the text of an ID128 should be 32 hexadecimal digits: " + string(text))
+	}
+	if _, err := hex.Decode(b[:], text); err != nil {
+		return err
+	}
+	*id = ID128FromBytes(b)
+	return nil
+}
+
+// MarshalBinary encodes the ID in 16 bytes in big-endian order.
+func (id ID128) MarshalBinary() ([]byte, error) {
+	b := id.Bytes()
+	return b[:], nil
+}
+
+// UnmarshalBinary decodes the ID from 16 bytes in big-endian order.
+func (id *ID128) UnmarshalBinary(data []byte) error {
+	var b [16]byte
+	if len(data) != 16 {
+		return errors.New("the binary form of an ID128 should be 16 bytes")
+	}
+	copy(b[:], data)
+	*id = ID128FromBytes(b)
+	return nil
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestNewWUID128(t *testing.T) {
+	if _, err := NewWUID128(nil); err == nil {
+		t.Fatal("NewWUID128 should fail when w is nil")
+	}
+	w := NewWUID("default", nil)
+	if _, err := NewWUID128(w); err == nil {
+		t.Fatal("NewWUID128 should fail when w has not been loaded yet")
+	}
+
+	w.ResetH28(42)
+	g1, err := NewWUID128(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g2, err := NewWUID128(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id1, id2 := g1.Next(), g2.Next()
+	if id1.Hi != 42<<36+1 || id2.Hi != 42<<36+2 || id1.Lo != 1 || id2.Lo != 1 {
+		t.Fatalf("NewWUID128 does not work as expected: %s, %s", id1, id2)
+	}
+}
+
+func TestWUID128_Next(t *testing.T) {
+	w := NewWUID("default", nil)
+	w.ResetH28(42)
+	g, _ := NewWUID128(w)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	m := make(map[ID128]struct{})
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				id := g.Next()
+				mu.Lock()
+				m[id] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(m) != 10000 {
+		t.Fatalf("WUID128.Next generates duplicate IDs: %d", len(m))
+	}
+}
+
+func TestWUID128_Next_Panic(t *testing.T) {
+	defer func() {
+		_ = recover()
+	}()
+
+	w := NewWUID("default", nil)
+	w.ResetH28(42)
+	g, _ := NewWUID128(w)
+	g.lo = ^uint64(0)
+	g.Next()
+
+	t.Fatal("should not be here")
+}
+
+func TestID128(t *testing.T) {
+	id := ID128{Hi: 0x000002a000000001, Lo: 5}
+	if s := id.String(); s != "000002a0000000010000000000000005" {
+		t.Fatalf("ID128.String does not work as expected: %s", s)
+	}
+	if ID128FromBytes(id.Bytes()) != id {
+		t.Fatal("ID128FromBytes does not work as expected")
+	}
+
+	data, err := json.Marshal(map[ID128]ID128{id: id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[ID128]ID128
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m[id] != id {
+		t.Fatalf("ID128 does not work as expected in JSON: %s", data)
+	}
+
+	b, _ := id.MarshalBinary()
+	var id2 ID128
+	if err := id2.UnmarshalBinary(b); err != nil || id2 != id {
+		t.Fatalf("ID128.UnmarshalBinary does not work as expected: %v", err)
+	}
+	if err := id2.UnmarshalBinary(b[1:]); err == nil {
+		t.Fatal("ID128.UnmarshalBinary should fail when the data is not 16 bytes")
+	}
+	if err := id2.UnmarshalText([]byte("xyz")); err == nil {
+		t.Fatal("ID128.UnmarshalText should fail when the text is invalid")
+	}
+}
@@ -1,9 +1,11 @@
 package internal
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"sort"
 	"sync"
 	"sync/atomic"
@@ -23,6 +25,73 @@ func TestWUID_Next(t *testing.T) {
 	}
 }
 
+func TestWUID_NextN(t *testing.T) {
+	g := NewWUID("default", nil)
+	v := atomic.LoadUint64(&g.N)
+	for _, n := range []int{1, 2, 10, 1000} {
+		a := g.NextN(n)
+		if len(a) != n {
+			t.Fatalf("len(a) is %d, while it should be %d", len(a), n)
+		}
+		for _, id := range a {
+			v++
+			if id != v {
+				t.Fatalf("the id is %d, while it should be %d", id, v)
+			}
+		}
+	}
+	if g.NextN(0) != nil || g.NextN(-1) != nil {
+		t.Fatal("NextN should return nil when n <= 0")
+	}
+	if id := g.Next(); id != v+1 {
+		t.Fatalf("the id is %d, while it should be %d", id, v+1)
+	}
+}
+
+func TestWUID_AppendNextN(t *testing.T) {
+	g := NewWUID("default", nil)
+	dst := []uint64{42}
+	dst = g.AppendNextN(dst, 3)
+	if len(dst) != 4 || dst[0] != 42 || dst[1] != 1 || dst[3] != 3 {
+		t.Fatalf("AppendNextN does not work as expected: %v", dst)
+	}
+	if len(g.AppendNextN(dst, 0)) != 4 {
+		t.Fatal("AppendNextN should leave dst as is when n <= 0")
+	}
+}
+
+func TestWUID_NextN_Panic(t *testing.T) {
+	defer func() {
+		_ = recover()
+	}()
+
+	g := NewWUID("default", nil)
+	atomic.StoreUint64(&g.N, PanicValue-5)
+	g.NextN(10)
+
+	t.Fatal("should not be here")
+}
+
+func TestWUID_NextN_Renew(t *testing.T) {
+	logger := &simpleLogger{}
+	g := NewWUID("default", logger)
+	g.Renew = func() error {
+		g.Reset(((atomic.LoadUint64(&g.N) >> 36) + 1) << 36)
+		return nil
+	}
+
+	n1 := g.Next()
+	kk := ((CriticalValue + RenewInterval) & ^RenewInterval) - 5
+	g.Reset((n1 >> 36 << 36) | kk)
+	g.NextN(10)
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	if n2>>36 == n1>>36 {
+		t.Fatalf("NextN does not trigger renew as expected: %x, %x", n1>>36, n2>>36)
+	}
+}
+
 type uint64Slice []uint64
 
 func (p uint64Slice) Len() int           { return len(p) }
@@ -57,12 +126,12 @@ func TestWUID_Next_Concurrent(t *testing.T) {
 }
 
 type simpleLogger struct {
-	numInfo int
-	numWarn int
+	numInfo int32
+	numWarn int32
 }
 
 func (this *simpleLogger) Info(args ...interface{}) {
-	this.numInfo++
+	atomic.AddInt32(&this.numInfo, 1)
 	if !testing.Verbose() {
 		return
 	}
@@ -72,7 +141,7 @@ func (this *simpleLogger) Info(args ...interface{}) {
 }
 
 func (this *simpleLogger) Warn(args ...interface{}) {
-	this.numWarn++
+	atomic.AddInt32(&this.numWarn, 1)
 	if !testing.Verbose() {
 		return
 	}
@@ -117,8 +186,8 @@ func TestWUID_Next_Renew(t *testing.T) {
 	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
 		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
 	}
-	if logger.numInfo != 2 {
-		t.Fatalf("there should be 2 renew logs of the info type. actual: %d", logger.numInfo)
+	if atomic.LoadInt32(&logger.numInfo) != 2 {
+		t.Fatalf("there should be 2 renew logs of the info type. actual: %d", atomic.LoadInt32(&logger.numInfo))
 	}
 }
 
@@ -142,8 +211,8 @@ func TestWUID_Next_Renew_Fail(t *testing.T) {
 	time.Sleep(time.Millisecond * 200)
 	g.Next()
 
-	if logger.numWarn != 2 {
-		t.Fatalf("there should be 2 renew logs of the warn type. actual: %d", logger.numWarn)
+	if atomic.LoadInt32(&logger.numWarn) != 2 {
+		t.Fatalf("there should be 2 renew logs of the warn type. actual: %d", atomic.LoadInt32(&logger.numWarn))
 	}
 }
 
@@ -161,6 +230,117 @@ func TestWUID_Next_Renew_Panic(t *testing.T) {
 	time.Sleep(time.Millisecond * 200)
 }
 
+func TestWUID_NextCtx(t *testing.T) {
+	g := NewWUID("default", nil)
+	v := atomic.LoadUint64(&g.N)
+	for i := 0; i < 100; i++ {
+		v++
+		id, err := g.NextCtx(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != v {
+			t.Fatalf("the id is %d, while it should be %d", id, v)
+		}
+	}
+}
+
+func TestWUID_NextCtx_Wait(t *testing.T) {
+	g := NewWUID("default", &simpleLogger{})
+	var attempts int32
+	g.Renew = func() error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errors.New("unreachable")
+		}
+		g.Reset(2 << 36)
+		return nil
+	}
+	g.Reset(1<<36 | PanicValue)
+
+	ctx, cancel := context.WithTimeout(context.Background(), RenewRetryDelay*5)
+	defer cancel()
+	id, err := g.NextCtx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 2<<36+1 {
+		t.Fatalf("the id is %x, while it should be %x", id, uint64(2<<36+1))
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("renew should be attempted 3 times. actual: %d", n)
+	}
+}
+
+func TestWUID_NextCtx_Canceled(t *testing.T) {
+	g := NewWUID("default", &simpleLogger{})
+	g.Renew = func() error {
+		return errors.New("unreachable")
+	}
+	g.Reset(1<<36 | PanicValue)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if _, err := g.NextCtx(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("NextCtx should return context.DeadlineExceeded. actual: %v", err)
+	}
+}
+
+func TestWUID_NextCtx_Carry(t *testing.T) {
+	g := NewWUID("default", nil, WithBitLayout(28, 20))
+	var mu sync.Mutex
+	h28, loaded := uint64(5), map[uint64]bool{5: true}
+	g.Renew = func() error {
+		time.Sleep(time.Millisecond * 300)
+		mu.Lock()
+		// Skip one h28 every time, so that a number carried into the next h28 is never valid.
+		h28 += 2
+		loaded[h28] = true
+		next := h28
+		mu.Unlock()
+		g.ResetH28(next)
+		return nil
+	}
+	g.ResetH28(5)
+	g.Reset(5<<20 | g.panicValue - 100)
+
+	const callers, calls = 50000, 10
+	ids := make([][]uint64, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				id, err := g.NextCtx(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids[i] = append(ids[i], id)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, a := range ids {
+		for _, id := range a {
+			if !loaded[id>>20] {
+				t.Fatalf("NextCtx returned a number of an h28 that was never loaded: %x", id)
+			}
+		}
+	}
+}
+
+func TestWUID_NextCtx_NoRenew(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Reset(1<<36 | PanicValue)
+	if _, err := g.NextCtx(context.Background()); err == nil {
+		t.Fatal("NextCtx should fail when there is nothing to renew with")
+	}
+}
+
 func TestWUID_VerifyH28(t *testing.T) {
 	g1 := NewWUID("default", nil)
 	if err := g1.VerifyH28(100); err != nil {
@@ -230,3 +410,1098 @@ func TestWithRenewCallback(t *testing.T) {
 		t.Fatal("the H28Verifier was not called")
 	}
 }
+
+func TestWithOnRenew(t *testing.T) {
+	var calls [][2]uint64
+	var errs []error
+	g := NewWUID("default", nil, WithOnRenew(func(oldH28, newH28 uint64) {
+		calls = append(calls, [2]uint64{oldH28, newH28})
+	}), WithOnRenewError(func(h28 uint64, err error) {
+		calls = append(calls, [2]uint64{h28, 0})
+		errs = append(errs, err)
+	}))
+	g.ResetH28(10)
+	g.Renew = func() error {
+		g.ResetH28(11)
+		return nil
+	}
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	g.Renew = func() error {
+		return errors.New("bomb")
+	}
+	if err := g.RenewNow(); err == nil {
+		t.Fatal("RenewNow should fail")
+	}
+	if len(calls) != 2 || calls[0] != [2]uint64{10, 11} || calls[1] != [2]uint64{11, 0} {
+		t.Fatalf("the renew hooks do not work as expected: %v", calls)
+	}
+	if len(errs) != 1 || errs[0].Error() != "bomb" {
+		t.Fatalf("the renew error hook does not work as expected: %v", errs)
+	}
+}
+
+func TestWithRenewTimeout(t *testing.T) {
+	g := NewWUID("default", nil, WithRenewTimeout(time.Millisecond*50))
+	g.Renew = func() error {
+		time.Sleep(time.Second)
+		return nil
+	}
+	start := time.Now()
+	if err := g.RenewNow(); !errors.Is(err, ErrRenewTimeout) {
+		t.Fatalf("RenewNow should return ErrRenewTimeout: %v", err)
+	}
+	if time.Since(start) > time.Millisecond*500 {
+		t.Fatal("RenewNow should not wait for the hung renew")
+	}
+
+	g.Renew = func() error {
+		panic("bomb")
+	}
+	if err := g.RenewNow(); err == nil || errors.Is(err, ErrRenewTimeout) {
+		t.Fatalf("RenewNow should turn the panic into an error: %v", err)
+	}
+
+	var cancelled int32
+	g.RenewCtx = func(ctx context.Context) error {
+		<-ctx.Done()
+		atomic.StoreInt32(&cancelled, 1)
+		return ctx.Err()
+	}
+	if err := g.RenewNow(); !errors.Is(err, ErrRenewTimeout) {
+		t.Fatalf("RenewNow should return ErrRenewTimeout: %v", err)
+	}
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Fatal("the context should be cancelled")
+	}
+
+	g.RenewCtx = func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Fatal("the context should have a deadline")
+		}
+		return nil
+	}
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("WithRenewTimeout should panic")
+		}
+	}()
+	WithRenewTimeout(0)
+}
+
+func TestWithDeadlineOf(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, 42)
+	ctx1, cancel1 := WithDeadlineOf(ctx, context.Background())
+	defer cancel1()
+	if _, ok := ctx1.Deadline(); ok {
+		t.Fatal("WithDeadlineOf should not add a deadline when rctx has none")
+	}
+	if ctx1.Value(key{}) != 42 {
+		t.Fatal("WithDeadlineOf should keep the values of ctx")
+	}
+
+	rctx, rcancel := context.WithTimeout(context.Background(), time.Minute)
+	defer rcancel()
+	ctx2, cancel2 := WithDeadlineOf(ctx, rctx)
+	defer cancel2()
+	d1, _ := rctx.Deadline()
+	if d2, ok := ctx2.Deadline(); !ok || !d2.Equal(d1) {
+		t.Fatal("WithDeadlineOf should take the deadline of rctx")
+	}
+	if ctx2.Value(key{}) != 42 {
+		t.Fatal("WithDeadlineOf should keep the values of ctx")
+	}
+}
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var calls int32
+	var fail int32 = 1
+	g := NewWUID("default", nil, WithCircuitBreaker(2, time.Millisecond*100))
+	g.Renew = func() error {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&fail) != 0 {
+			return errors.New("bomb")
+		}
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if err := g.RenewNow(); err == nil || errors.Is(err, ErrBreakerOpen) {
+			t.Fatalf("RenewNow should call the data store: %v", err)
+		}
+	}
+	if stats := g.Stats(); stats.Failures != 2 || !stats.BreakerOpen {
+		t.Fatalf("the circuit breaker should be open: %+v", stats)
+	}
+	for i := 0; i < 3; i++ {
+		if err := g.RenewNow(); !errors.Is(err, ErrBreakerOpen) {
+			t.Fatalf("RenewNow should return ErrBreakerOpen: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("the data store should not be called while the breaker is open: %d", calls)
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	if err := g.RenewNow(); err == nil || errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("RenewNow should probe the data store: %v", err)
+	}
+	if err := g.RenewNow(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("the failed probe should reopen the breaker: %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	atomic.StoreInt32(&fail, 0)
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := g.Stats(); stats.Failures != 0 || stats.BreakerOpen {
+		t.Fatalf("the circuit breaker should be closed: %+v", stats)
+	}
+
+	for _, f := range []func(){
+		func() { WithCircuitBreaker(0, time.Second) },
+		func() { WithCircuitBreaker(1, 0) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("WithCircuitBreaker should panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestWithPrefetch(t *testing.T) {
+	var h28 uint64 = 1
+	g := NewWUID("default", nil, WithPrefetch())
+	g.Renew = func() error {
+		g.ResetH28(atomic.AddUint64(&h28, 1))
+		return nil
+	}
+	g.ResetH28(1)
+	if g.H28() != 1 || g.Stats().Spare != 0 {
+		t.Fatal("the first h28 should be applied immediately")
+	}
+
+	kk := ((CriticalValue + RenewInterval) & ^RenewInterval) - 1
+	g.Reset(1<<36 | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 100)
+	if g.H28() != 1 || g.Stats().Spare != 2 {
+		t.Fatalf("the renew should fill the spare block. h28: %d, spare: %d", g.H28(), g.Stats().Spare)
+	}
+	g.Reset(1<<36 | (kk + RenewInterval + 1))
+	g.Next()
+	time.Sleep(time.Millisecond * 100)
+	if atomic.LoadUint64(&h28) != 2 {
+		t.Fatal("there should be no renew while the spare block is full")
+	}
+
+	g.Reset(1<<36 | PanicValue - 1)
+	if n := g.Next(); n>>36 != 2 || n&(1<<36-1) != 1 {
+		t.Fatalf("Next should switch to the spare block: %x", n)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if g.Stats().Spare != 3 {
+		t.Fatalf("the spare block should be refilled: %d", g.Stats().Spare)
+	}
+
+	g.Reset(2<<36 | PanicValue - 1)
+	if _, err := g.NextE(); err != nil {
+		t.Fatal(err)
+	}
+	if g.H28() != 3 {
+		t.Fatalf("NextE should switch to the spare block: %d", g.H28())
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("WithPrefetch should not be combined with rotation")
+		}
+	}()
+	NewWUID("default", nil, WithPrefetch(), WithRotation(time.Second))
+}
+
+func TestWithExhaustionPolicy(t *testing.T) {
+	recoverErr := func(fn func()) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Fatal("it should panic")
+			}
+			err, _ = r.(error)
+		}()
+		fn()
+		return nil
+	}
+
+	g := NewWUID("default", nil)
+	g.ResetH28(1)
+	g.Reset(1<<36 | PanicValue)
+	if err := recoverErr(func() { g.Next() }); err != nil {
+		t.Fatalf("ExhaustionPanic should panic with a string: %v", err)
+	}
+
+	g = NewWUID("default", nil, WithExhaustionPolicy(ExhaustionError, 0))
+	g.ResetH28(1)
+	g.Reset(1<<36 | PanicValue)
+	if err := recoverErr(func() { g.Next() }); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("ExhaustionError should panic with ErrExhausted: %v", err)
+	}
+	_ = g.Close()
+	if err := recoverErr(func() { g.Next() }); !errors.Is(err, ErrClosed) {
+		t.Fatalf("ExhaustionError should panic with ErrClosed: %v", err)
+	}
+
+	g = NewWUID("default", nil, WithExhaustionPolicy(ExhaustionBlock, time.Second*3))
+	g.Renew = func() error {
+		time.Sleep(time.Millisecond * 100)
+		g.ResetH28(2)
+		return nil
+	}
+	g.ResetH28(1)
+	g.Reset(1<<36 | PanicValue)
+	if n := g.Next(); n>>36 != 2 {
+		t.Fatalf("ExhaustionBlock should wait for the renew: %x", n)
+	}
+	g.Reset(2<<36 | PanicValue)
+	g.Renew = func() error {
+		return errors.New("bomb")
+	}
+	g.exhaustionTimeout = time.Millisecond * 100
+	if err := recoverErr(func() { g.NextN(3) }); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("ExhaustionBlock should panic with ErrExhausted after the timeout: %v", err)
+	}
+
+	// NextN carries the low bits into the next h28 when the batch does not fit.
+	g = NewWUID("default", nil, WithBitLayout(28, 20))
+	g.ResetH28(5)
+	g.Reset(5<<20 | g.panicValue - 10)
+	_ = recoverErr(func() { g.NextN(50000) })
+	if h28 := g.H28(); h28 != 5 {
+		t.Fatalf("the h28 should be kept after the panic: %d", h28)
+	}
+
+	g = NewWUID("default", nil, WithBitLayout(28, 20), WithExhaustionPolicy(ExhaustionBlock, time.Second*3))
+	g.Renew = func() error {
+		time.Sleep(time.Millisecond * 50)
+		g.ResetH28(7)
+		return nil
+	}
+	g.ResetH28(5)
+	g.Reset(5<<20 | g.panicValue - 10)
+	for _, id := range g.NextN(50000) {
+		if id>>20 != 7 {
+			t.Fatalf("ExhaustionBlock should take the batch from the renewed h28: %x", id)
+		}
+	}
+
+	for _, f := range []func(){
+		func() { WithExhaustionPolicy(ExhaustionBlock, 0) },
+		func() { WithExhaustionPolicy(ExhaustionBlock+1, time.Second) },
+	} {
+		if err := recoverErr(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWithJSSafe(t *testing.T) {
+	const maxSafeInteger = 1<<53 - 1
+	g := NewWUID("default", nil, WithJSSafe())
+	if g.MaxH28() != 0x1FFFFF {
+		t.Fatalf("MaxH28 does not work as expected: %x", g.MaxH28())
+	}
+	if err := g.VerifyH28(0x200000); err == nil {
+		t.Fatal("VerifyH28 does not work as expected. n: 0x200000")
+	}
+
+	g.ResetH28(g.MaxH28())
+	if g.H28() != 0x1FFFFF {
+		t.Fatalf("H28 does not work as expected: %x", g.H28())
+	}
+	if n := g.Next(); n > maxSafeInteger || n != 0x1FFFFF<<32+1 {
+		t.Fatalf("WithJSSafe does not work as expected: %x", n)
+	}
+
+	g = NewWUID("default", nil, WithJSSafe(), WithSection(15))
+	g.ResetH28(g.MaxH28())
+	if n := g.Next(); n > maxSafeInteger || n>>49 != 15 || g.H28() != 0x1FFFF {
+		t.Fatalf("WithJSSafe does not work as expected with a section ID: %x", n)
+	}
+}
+
+func TestWithJSSafe_Renew(t *testing.T) {
+	logger := &simpleLogger{}
+	g := NewWUID("default", logger, WithJSSafe())
+	g.Renew = func() error {
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+
+	g.ResetH28(1)
+	renewInterval := uint64(1)<<(JSSafeLowBits-6) - 1
+	criticalValue := uint64(1) << JSSafeLowBits * 80 / 100
+	kk := ((criticalValue + renewInterval) & ^renewInterval) - 1
+	g.Reset(1<<JSSafeLowBits | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	if g.H28() != 2 || atomic.LoadInt32(&logger.numInfo) != 1 {
+		t.Fatalf("the renew mechanism does not work as expected. h28: %d", g.H28())
+	}
+}
+
+func TestWithJSSafe_Panic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "<wuid> the low 32 bits are about to run out" {
+			t.Fatalf("Next does not panic as expected: %v", r)
+		}
+	}()
+
+	g := NewWUID("default", nil, WithJSSafe())
+	atomic.StoreUint64(&g.N, uint64(1)<<JSSafeLowBits*96/100)
+	g.Next()
+
+	t.Fatal("should not be here")
+}
+
+func TestWithBitLayout(t *testing.T) {
+	layouts := [][2]uint8{{8, 56}, {28, 36}, {24, 40}, {44, 20}, {21, 32}}
+	for _, l := range layouts {
+		hBits, lowBits := l[0], l[1]
+		g := NewWUID("default", nil, WithBitLayout(hBits, lowBits))
+		if g.MaxH28() != 1<<hBits-1 {
+			t.Fatalf("MaxH28 does not work as expected: %x. layout: %v", g.MaxH28(), l)
+		}
+		if err := g.VerifyH28(1 << hBits); err == nil {
+			t.Fatalf("VerifyH28 does not work as expected. layout: %v", l)
+		}
+		g.ResetH28(g.MaxH28())
+		if n := g.Next(); n != (1<<hBits-1)<<lowBits+1 || g.H28() != g.MaxH28() {
+			t.Fatalf("WithBitLayout does not work as expected: %x. layout: %v", n, l)
+		}
+
+		g = NewWUID("default", nil, WithBitLayout(hBits, lowBits), WithSection(3))
+		if g.MaxH28() != 1<<(hBits-4)-1 {
+			t.Fatalf("MaxH28 does not work as expected with a section ID: %x. layout: %v", g.MaxH28(), l)
+		}
+		g.ResetH28(g.MaxH28())
+		if n := g.Next(); n>>(hBits+lowBits-4) != 3 || g.H28() != g.MaxH28() {
+			t.Fatalf("WithBitLayout does not work as expected with a section ID: %x. layout: %v", n, l)
+		}
+	}
+}
+
+func TestWithBitLayout_Renew(t *testing.T) {
+	for _, lowBits := range []uint8{20, 36, 56} {
+		logger := &simpleLogger{}
+		g := NewWUID("default", logger, WithBitLayout(8, lowBits))
+		g.Renew = func() error {
+			g.ResetH28(g.H28() + 1)
+			return nil
+		}
+
+		g.ResetH28(1)
+		renewInterval := uint64(1)<<(lowBits-6) - 1
+		criticalValue := uint64(1) << lowBits * 80 / 100
+		kk := ((criticalValue + renewInterval) & ^renewInterval) - 1
+		g.Reset(1<<lowBits | kk)
+		g.Next()
+		time.Sleep(time.Millisecond * 200)
+		if g.H28() != 2 || atomic.LoadInt32(&logger.numInfo) != 1 {
+			t.Fatalf("the renew mechanism does not work as expected. h28: %d, lowBits: %d", g.H28(), lowBits)
+		}
+	}
+}
+
+func TestWithBitLayout_Panic(t *testing.T) {
+	layouts := [][2]uint8{{28, 19}, {7, 36}, {28, 57}, {9, 56}, {45, 20}}
+	for _, l := range layouts {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			WithBitLayout(l[0], l[1])
+			t.Fatalf("WithBitLayout should reject the layout: %v", l)
+		}()
+	}
+}
+
+func TestWithSignedSafe(t *testing.T) {
+	g := NewWUID("default", nil, WithSignedSafe())
+	if err := g.VerifyH28(0x8000000); err == nil {
+		t.Fatal("VerifyH28 does not work as expected. n: 0x8000000")
+	}
+	g.ResetH28(g.MaxH28())
+	if n := g.Next(); int64(n) < 0 || n != 0x7FFFFFF<<36+1 {
+		t.Fatalf("WithSignedSafe does not work as expected: %x", n)
+	}
+
+	g = NewWUID("default", nil, WithSignedSafe(), WithSection(15))
+	g.ResetH28(g.MaxH28())
+	if n := g.Next(); int64(n) < 0 || n>>59 != 15 {
+		t.Fatalf("WithSignedSafe does not work as expected with a section ID: %x", n)
+	}
+}
+
+func TestWithStep(t *testing.T) {
+	g := NewWUID("default", nil, WithStep(10))
+	g.ResetH28(42)
+	if n1, n2 := g.Next(), g.Next(); n1 != 42<<36+10 || n2 != 42<<36+20 {
+		t.Fatalf("WithStep does not work as expected: %x, %x", n1, n2)
+	}
+	ids := g.NextN(3)
+	if len(ids) != 3 || ids[0] != 42<<36+30 || ids[1] != 42<<36+40 || ids[2] != 42<<36+50 {
+		t.Fatalf("WithStep does not work as expected with NextN: %x", ids)
+	}
+	if n, err := g.NextCtx(context.Background()); err != nil || n != 42<<36+60 {
+		t.Fatalf("WithStep does not work as expected with NextCtx: %x, %v", n, err)
+	}
+}
+
+func TestWithStep_Renew(t *testing.T) {
+	logger := &simpleLogger{}
+	g := NewWUID("default", logger, WithStep(7))
+	g.Renew = func() error {
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+
+	g.ResetH28(1)
+	kk := ((CriticalValue + RenewInterval) & ^RenewInterval) - 3
+	g.Reset(1<<36 | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	if g.H28() != 2 || atomic.LoadInt32(&logger.numInfo) != 1 {
+		t.Fatalf("the renew mechanism does not work as expected with WithStep. h28: %d", g.H28())
+	}
+}
+
+func TestWithStep_Panic(t *testing.T) {
+	for _, step := range []uint64{0, MaxStep + 1} {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			WithStep(step)
+			t.Fatalf("WithStep should reject the step: %d", step)
+		}()
+	}
+}
+
+func TestWithSkipFunc(t *testing.T) {
+	g := NewWUID("default", nil, WithSkipFunc(func(n uint64) bool {
+		return n%3 == 0
+	}), WithSkipFunc(nil), WithSkipValues(42<<36+4))
+	g.ResetH28(42)
+	for i := 0; i < 100; i++ {
+		if n := g.Next(); n%3 == 0 || n == 42<<36+4 {
+			t.Fatalf("Next should skip %x", n)
+		}
+	}
+	for _, n := range g.NextN(100) {
+		if n%3 == 0 {
+			t.Fatalf("NextN should skip %x", n)
+		}
+	}
+	if ids := g.NextN(100); len(ids) != 100 {
+		t.Fatalf("NextN should top up the skipped numbers: %d", len(ids))
+	}
+	for i := 0; i < 100; i++ {
+		if n, _ := g.NextCtx(context.Background()); n%3 == 0 {
+			t.Fatalf("NextCtx should skip %x", n)
+		}
+	}
+}
+
+func TestWithSkipValues(t *testing.T) {
+	g := NewWUID("default", nil, WithSkipValues(42<<36+1, 42<<36+2))
+	g.ResetH28(42)
+	if n := g.Next(); n != 42<<36+3 {
+		t.Fatalf("WithSkipValues does not work as expected: %x", n)
+	}
+}
+
+func TestWithSkipAllOnes(t *testing.T) {
+	g := NewWUID("default", nil, WithSkipAllOnes(32))
+	g.Reset(42<<36 | 0xFFFFFFFE)
+	if n := g.Next(); n != 42<<36|0x100000000 {
+		t.Fatalf("WithSkipAllOnes does not work as expected: %x", n)
+	}
+	if ids := g.NextN(2); ids[0] != 42<<36|0x100000001 {
+		t.Fatalf("WithSkipAllOnes does not work as expected: %x", ids)
+	}
+
+	for _, bits := range []uint8{0, 65} {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			WithSkipAllOnes(bits)
+			t.Fatalf("WithSkipAllOnes should reject the bits: %d", bits)
+		}()
+	}
+}
+
+func TestWUID_Peek(t *testing.T) {
+	g := NewWUID("default", nil, WithStep(2), WithSkipValues(42<<36+4))
+	g.ResetH28(42)
+	if g.LastIssued() != 0 {
+		t.Fatalf("LastIssued should return 0 before any number is generated: %x", g.LastIssued())
+	}
+	if p := g.Peek(); p != 42<<36+2 || g.Peek() != p {
+		t.Fatalf("Peek does not work as expected: %x", p)
+	}
+	if n := g.Next(); n != 42<<36+2 || g.LastIssued() != n {
+		t.Fatalf("LastIssued does not work as expected: %x, %x", n, g.LastIssued())
+	}
+	if p := g.Peek(); p != 42<<36+6 || g.Next() != p {
+		t.Fatalf("Peek does not work as expected with WithSkipValues: %x", p)
+	}
+}
+
+func TestWUID_Stats(t *testing.T) {
+	g := NewWUID("default", nil, WithSection(3))
+	g.Renew = func() error {
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+	g.ResetH28(42)
+	g.NextN(10)
+
+	stats := g.Stats()
+	if stats.H28 != 42 || stats.Section != 3 || stats.Consumed != 10 || stats.Remaining != PanicValue-11 {
+		t.Fatalf("Stats does not work as expected: %+v", stats)
+	}
+	if stats.Renews != 0 || !stats.LastRenewAt.IsZero() || stats.LastRenewErr != nil {
+		t.Fatalf("Stats does not work as expected: %+v", stats)
+	}
+
+	if err := g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	g.Renew = func() error {
+		return errors.New("foo")
+	}
+	_ = g.RenewNow()
+	stats = g.Stats()
+	if stats.H28 != 43 || stats.Consumed != 0 || stats.Renews != 1 || stats.LastRenewAt.IsZero() || stats.LastRenewErr == nil {
+		t.Fatalf("Stats does not work as expected: %+v", stats)
+	}
+
+	atomic.StoreUint64(&g.N, PanicValue)
+	if stats = g.Stats(); stats.Remaining != 0 {
+		t.Fatalf("Stats does not work as expected: %+v", stats)
+	}
+}
+
+func TestWUID_Healthy(t *testing.T) {
+	g := NewWUID("default", nil, WithCircuitBreaker(2, time.Hour))
+	if err := g.Healthy(); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("Healthy should return ErrNotLoaded: %v", err)
+	}
+	g.ResetH28(42)
+	if err := g.Healthy(); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Renew = func() error {
+		return errors.New("foo")
+	}
+	_ = g.RenewNow()
+	if err := g.Healthy(); err != nil {
+		t.Fatalf("a failed renew should not matter while the block is far from running out: %v", err)
+	}
+	atomic.StoreUint64(&g.N, 42<<36|CriticalValue)
+	if err := g.Healthy(); !errors.Is(err, ErrRenewFailing) {
+		t.Fatalf("Healthy should return ErrRenewFailing: %v", err)
+	}
+	atomic.StoreUint64(&g.N, 42<<36|PanicValue)
+	if err := g.Healthy(); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("Healthy should return ErrExhausted: %v", err)
+	}
+	_ = g.RenewNow()
+	if err := g.Healthy(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("Healthy should return ErrBreakerOpen: %v", err)
+	}
+
+	_ = g.Close()
+	if err := g.Healthy(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Healthy should return ErrClosed: %v", err)
+	}
+}
+
+func TestWUID_Close(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Renew = func() error {
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+	g.ResetH28(42)
+	g.Next()
+
+	var closed []int
+	g.AddCloser(func() error {
+		closed = append(closed, 1)
+		return nil
+	})
+	g.AddCloser(func() error {
+		closed = append(closed, 2)
+		return errors.New("foo")
+	})
+	if err := g.Close(); err == nil || err.Error() != "foo" {
+		t.Fatalf("Close should return the errors of the closers: %v", err)
+	}
+	if len(closed) != 2 || closed[0] != 2 || closed[1] != 1 {
+		t.Fatalf("the closers should be called in reverse order: %v", closed)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close should do nothing when it is called again: %v", err)
+	}
+
+	if err := g.RenewNow(); err == nil {
+		t.Fatal("RenewNow should fail after Close is called")
+	}
+	g.ResetH28(43)
+	for i := 0; i < 3; i++ {
+		func() {
+			defer func() {
+				if r := recover(); r != "<wuid> the generator has been closed. tag: default" {
+					t.Fatalf("Next does not panic as expected: %v", r)
+				}
+			}()
+			g.Next()
+			t.Fatal("should not be here")
+		}()
+	}
+	if _, err := g.NextCtx(context.Background()); err == nil {
+		t.Fatal("NextCtx should fail after Close is called")
+	}
+}
+
+func TestWUID_Close_RenewCtx(t *testing.T) {
+	g := NewWUID("default", nil, WithRenewTimeout(time.Second), WithBlocksPerRenew(4))
+	var calls int32
+	g.Renew = func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+	g.RenewCtx = func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+	g.ReserveH28 = func(k uint64) (uint64, error) {
+		atomic.AddInt32(&calls, 1)
+		return 42, nil
+	}
+	g.ResetH28(42)
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.RenewNow(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("RenewNow should return ErrClosed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 0 {
+		t.Fatalf("the loader should not be called after Close: %d", n)
+	}
+}
+
+func TestWUID_Close_NextCtx(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.Renew = func() error {
+		return errors.New("foo")
+	}
+	atomic.StoreUint64(&g.N, PanicValue)
+
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		_ = g.Close()
+	}()
+	if _, err := g.NextCtx(context.Background()); err == nil {
+		t.Fatal("NextCtx should fail after Close is called")
+	}
+}
+
+func TestWithSectionWidth(t *testing.T) {
+	g := NewWUID("default", nil, WithSectionWidth(8))
+	if g.MaxH28() != 0xFFFFF {
+		t.Fatalf("MaxH28 does not work as expected: %x", g.MaxH28())
+	}
+	g.ResetH28(42)
+	if n := g.Next(); n != 42<<36+1 {
+		t.Fatalf("WithSectionWidth does not work as expected: %x", n)
+	}
+	for _, section := range []uint8{0, 1, 200, 255} {
+		n := g.NextWithSection(section)
+		if n>>56 != uint64(section) || n>>36&g.MaxH28() != 42 {
+			t.Fatalf("NextWithSection does not work as expected: %x", n)
+		}
+	}
+
+	g = NewWUID("default", nil, WithSectionWidth(2), WithSection(3), WithJSSafe())
+	g.ResetH28(g.MaxH28())
+	if n := g.Next(); n>>51 != 3 || g.H28() != 0x7FFFF {
+		t.Fatalf("WithSectionWidth does not work as expected: %x", n)
+	}
+	if n := g.NextWithSection(1); n>>51 != 1 || n >= 1<<53 {
+		t.Fatalf("NextWithSection does not work as expected: %x", n)
+	}
+}
+
+func TestWithSectionWidth_Panic(t *testing.T) {
+	cases := []func(){
+		func() { WithSectionWidth(0) },
+		func() { WithSectionWidth(9) },
+		func() { NewWUID("default", nil, WithSectionWidth(2), WithSection(4)) },
+		func() { NewWUID("default", nil, WithSectionWidth(8), WithBitLayout(8, 56)) },
+		func() { NewWUID("default", nil).NextWithSection(1) },
+		func() { NewWUID("default", nil, WithSectionWidth(2)).NextWithSection(4) },
+	}
+	for i, fn := range cases {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			fn()
+			t.Fatalf("case %d should panic", i)
+		}()
+	}
+}
+
+func TestWUID_NextE(t *testing.T) {
+	g := NewWUID("default", nil, WithSkipValues(42<<36+1))
+	if _, err := g.NextE(); !errors.Is(err, ErrNotLoaded) {
+		t.Fatalf("NextE should return ErrNotLoaded: %v", err)
+	}
+
+	g.ResetH28(42)
+	if n, err := g.NextE(); err != nil || n != 42<<36+2 {
+		t.Fatalf("NextE does not work as expected: %x, %v", n, err)
+	}
+
+	var renews int32
+	g.Renew = func() error {
+		atomic.AddInt32(&renews, 1)
+		return errors.New("foo")
+	}
+	g.Reset(42<<36 | PanicValue)
+	for i := 0; i < 3; i++ {
+		if _, err := g.NextE(); !errors.Is(err, ErrExhausted) {
+			t.Fatalf("NextE should return ErrExhausted: %v", err)
+		}
+	}
+	time.Sleep(time.Millisecond * 100)
+	if atomic.LoadInt32(&renews) == 0 {
+		t.Fatal("NextE should renew when the low bits have run out")
+	}
+
+	_ = g.Close()
+	if _, err := g.NextE(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("NextE should return ErrClosed: %v", err)
+	}
+	if _, err := g.NextCtx(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("NextCtx should return ErrClosed: %v", err)
+	}
+}
+
+func TestWUID_NextE_AfterNextPanic(t *testing.T) {
+	g := NewWUID("default", nil)
+	g.ResetH28(42)
+	g.Reset(42<<36 | PanicValue)
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("Next should panic when the low bits have run out")
+			}
+		}()
+		g.Next()
+	}()
+	if g.H28() != 42 {
+		t.Fatalf("the h28 should survive the exhaustion: %d", g.H28())
+	}
+	if _, err := g.NextE(); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("NextE should return ErrExhausted: %v", err)
+	}
+}
+
+func TestWithRotation(t *testing.T) {
+	logger := &simpleLogger{}
+	g := NewWUID("default", logger, WithRotation(time.Second))
+	g.Renew = func() error {
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+	g.ResetH28(1)
+	if d := g.untilRotation(); d <= 0 || d > time.Second {
+		t.Fatalf("untilRotation does not work as expected: %s", d)
+	}
+	time.Sleep(time.Millisecond * 2200)
+	if h28 := g.H28(); h28 < 2 || h28 > 4 {
+		t.Fatalf("WithRotation does not work as expected. h28: %d", h28)
+	}
+
+	_ = g.Close()
+	h28 := g.H28()
+	time.Sleep(time.Millisecond * 1200)
+	if g.H28() != h28 {
+		t.Fatal("the rotation should stop after Close")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("WithRotation should panic")
+		}
+	}()
+	WithRotation(time.Millisecond)
+}
+
+func TestWithRenewThreshold(t *testing.T) {
+	g := NewWUID("default", nil)
+	if g.criticalValue != CriticalValue || g.panicValue != PanicValue || g.renewInterval != RenewInterval {
+		t.Fatal("the default threshold should not change")
+	}
+	g = NewWUID("default", nil, WithRenewThreshold(0.8))
+	if g.criticalValue != CriticalValue || g.panicValue != PanicValue || g.renewInterval != RenewInterval {
+		t.Fatal("WithRenewThreshold(0.8) should keep the default values")
+	}
+
+	for _, fraction := range []float64{0.01, 0.5, 0.9, 0.99} {
+		g := NewWUID("default", nil, WithRenewThreshold(fraction), WithJSSafe())
+		size := uint64(1) << g.lowBits
+		if g.criticalValue != uint64(float64(size)*fraction) {
+			t.Fatalf("WithRenewThreshold does not work as expected. fraction: %v", fraction)
+		}
+		if g.panicValue <= g.criticalValue || g.panicValue >= size {
+			t.Fatalf("invalid panic value. fraction: %v", fraction)
+		}
+		if fraction < 0.8 && g.panicValue != size*96/100 {
+			t.Fatalf("the panic value should not depend on a lower threshold. fraction: %v", fraction)
+		}
+		if (g.panicValue-g.criticalValue)/(g.renewInterval+1) < 8 {
+			t.Fatalf("the renew interval is too large. fraction: %v", fraction)
+		}
+	}
+
+	var renewed int32
+	g = NewWUID("default", nil, WithRenewThreshold(0.5))
+	g.Renew = func() error {
+		atomic.AddInt32(&renewed, 1)
+		return nil
+	}
+	g.Reset(1<<36 | g.criticalValue - 2)
+	g.Next()
+	time.Sleep(time.Millisecond * 100)
+	if atomic.LoadInt32(&renewed) != 0 {
+		t.Fatal("the renew should not fire before the threshold")
+	}
+	g.Next()
+	time.Sleep(time.Millisecond * 100)
+	if atomic.LoadInt32(&renewed) != 1 {
+		t.Fatal("the renew should fire at the threshold")
+	}
+
+	for _, fraction := range []float64{0, 0.001, 1, -0.5, math.NaN()} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatalf("WithRenewThreshold should panic. fraction: %v", fraction)
+				}
+			}()
+			WithRenewThreshold(fraction)
+		}()
+	}
+}
+
+func TestWithRegion(t *testing.T) {
+	g := NewWUID("default", nil, WithRegion(5, 3))
+	if g.MaxH28() != 0x1FFFFFF {
+		t.Fatalf("MaxH28 does not work as expected: %x", g.MaxH28())
+	}
+	g.ResetH28(0xFFFFFFF)
+	if n := g.Next(); n>>61 != 5 || g.H28() != 0x1FFFFFF {
+		t.Fatalf("WithRegion does not work as expected: %x", n)
+	}
+
+	g = NewWUID("default", nil, WithSection(3), WithRegion(1, 2))
+	g.ResetH28(42)
+	if n := g.Next(); n>>60 != 3 || n>>58&3 != 1 || g.H28() != 42 {
+		t.Fatalf("WithRegion does not work as expected: %x", n)
+	}
+
+	g = NewWUID("default", nil, WithSectionWidth(2), WithRegion(2, 2))
+	g.ResetH28(42)
+	if n := g.NextWithSection(1); n>>62 != 1 || n>>60&3 != 2 || n>>36&g.MaxH28() != 42 {
+		t.Fatalf("NextWithSection does not work as expected: %x", n)
+	}
+}
+
+func TestWithRegion_Panic(t *testing.T) {
+	cases := []func(){
+		func() { WithRegion(0, 0) },
+		func() { WithRegion(0, 9) },
+		func() { WithRegion(4, 2) },
+		func() { NewWUID("default", nil, WithRegion(1, 8), WithBitLayout(8, 56)) },
+		func() { NewWUID("default", nil, WithSectionWidth(4), WithRegion(1, 4), WithBitLayout(8, 56)) },
+	}
+	for i, fn := range cases {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			fn()
+			t.Fatalf("case %d should panic", i)
+		}()
+	}
+}
+
+func TestWithTimePrefix(t *testing.T) {
+	g := NewWUID("default", nil, WithTimePrefix(16, time.Hour))
+	if g.MaxH28() != 0xFFF {
+		t.Fatalf("MaxH28 does not work as expected: %x", g.MaxH28())
+	}
+	g.ResetH28(0xFFFF)
+	if g.H28() != 0xFFF {
+		t.Fatalf("H28 does not work as expected: %x", g.H28())
+	}
+	bucket := uint64(time.Since(TimePrefixEpoch) / time.Hour)
+	n := g.Next()
+	if n>>48 != bucket && n>>48 != bucket+1 {
+		t.Fatalf("WithTimePrefix does not work as expected: %x", n)
+	}
+	if n&(1<<48-1) != 0xFFF<<36+1 {
+		t.Fatalf("WithTimePrefix does not work as expected: %x", n)
+	}
+	if p := g.Peek(); p>>48 != n>>48 || p&(1<<48-1) != 0xFFF<<36+2 {
+		t.Fatalf("Peek does not work as expected: %x", p)
+	}
+
+	g = NewWUID("default", nil, WithTimePrefix(4, time.Millisecond), WithSectionWidth(4))
+	g.ResetH28(42)
+	for i := 0; i < 100; i++ {
+		n := g.NextWithSection(9)
+		if n>>56&0xF != 9 || n>>36&g.MaxH28() != 42 {
+			t.Fatalf("NextWithSection does not work as expected: %x", n)
+		}
+	}
+}
+
+func TestWithTimePrefix_Panic(t *testing.T) {
+	cases := []func(){
+		func() { WithTimePrefix(0, time.Hour) },
+		func() { WithTimePrefix(41, time.Hour) },
+		func() { WithTimePrefix(8, time.Microsecond) },
+		func() { NewWUID("default", nil, WithTimePrefix(28, time.Hour)) },
+		func() { NewWUID("default", nil, WithTimePrefix(20, time.Hour), WithSectionWidth(8)) },
+		func() { NewWUID("default", nil, WithTimePrefix(8, time.Hour), WithObfuscation(testObfuscationKey)) },
+		func() { NewWUID("default", nil, WithTimePrefix(8, time.Hour), WithSnowflake(time.Time{})) },
+	}
+	for i, fn := range cases {
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			fn()
+			t.Fatalf("case %d should panic", i)
+		}()
+	}
+}
+
+func TestWithLayoutVersion(t *testing.T) {
+	g := NewWUID("default", nil, WithLayoutVersion(2))
+	if g.MaxH28() != 0x3FFFFFF {
+		t.Fatalf("MaxH28 does not work as expected: %x", g.MaxH28())
+	}
+	g.ResetH28(42)
+	if n := g.Next(); LayoutVersion(n) != 2 || n&(1<<62-1) != 42<<36+1 {
+		t.Fatalf("WithLayoutVersion does not work as expected: %x", n)
+	}
+
+	g = NewWUID("default", nil, WithLayoutVersion(1), WithSection(3), WithTimePrefix(16, time.Hour))
+	g.ResetH28(42)
+	n := g.Next()
+	if LayoutVersion(n) != 1 || n>>42&0xF != 3 || g.H28() != 42 {
+		t.Fatalf("WithLayoutVersion does not work as expected: %x", n)
+	}
+	lo, hi, err := g.IDRangeForInterval(time.Now().Add(-time.Hour), time.Now())
+	if err != nil || LayoutVersion(lo) != 1 || LayoutVersion(hi) != 1 || n < lo || n > hi {
+		t.Fatalf("IDRangeForInterval does not work as expected: %x, %x, %x, %v", lo, n, hi, err)
+	}
+
+	if NewWUID("default", nil).MaxH28() != 0xFFFFFFF {
+		t.Fatal("the version bits should be off by default")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("WithLayoutVersion should panic")
+		}
+	}()
+	WithLayoutVersion(4)
+}
+
+func TestWUID_Renew_Backoff(t *testing.T) {
+	g := NewWUID("default", &simpleLogger{})
+	var calls int32
+	g.Renew = func() error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.New("foo")
+		}
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+	g.ResetH28(1)
+	kk := ((CriticalValue + RenewInterval) & ^RenewInterval) - 1
+	g.Reset(1<<36 | kk)
+	g.Next()
+
+	time.Sleep(time.Millisecond * 200)
+	if atomic.LoadInt32(&calls) != 1 || g.H28() != 1 {
+		t.Fatal("the first renew should fail")
+	}
+	time.Sleep(RenewRetryDelay * 3 / 2)
+	if atomic.LoadInt32(&calls) != 2 || g.H28() != 2 {
+		t.Fatalf("the failed renew should be retried. calls: %d, h28: %d", atomic.LoadInt32(&calls), g.H28())
+	}
+	if atomic.LoadInt32(&g.retrying) != 0 {
+		t.Fatal("the retry goroutine should exit after the renew succeeds")
+	}
+}
+
+func TestWUID_Renew_Backoff_Exhausted(t *testing.T) {
+	g := NewWUID("default", nil)
+	var calls int32
+	g.Renew = func() error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return errors.New("foo")
+		}
+		g.ResetH28(g.H28() + 1)
+		return nil
+	}
+	g.ResetH28(1)
+	kk := ((CriticalValue + RenewInterval) & ^RenewInterval) - 1
+	g.Reset(1<<36 | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+
+	// Run out of the low bits while the retry is pending
+	g.Reset(1<<36 | PanicValue)
+	func() {
+		defer func() {
+			_ = recover()
+		}()
+		g.Next()
+	}()
+	time.Sleep(RenewRetryDelay * 3 / 2)
+	if atomic.LoadInt32(&calls) != 2 || g.H28() != 2 {
+		t.Fatalf("the retry should survive the exhaustion. calls: %d, h28: %d", atomic.LoadInt32(&calls), g.H28())
+	}
+}
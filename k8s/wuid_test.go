@@ -0,0 +1,141 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getConfigMapConfig() (string, string) {
+	return "default", "wuid"
+}
+
+func TestWUID_LoadH28FromConfigMap(t *testing.T) {
+	namespace, name := getConfigMapConfig()
+	client := fake.NewSimpleClientset()
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromConfigMap(client, namespace, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromConfigMap_Conflict(t *testing.T) {
+	namespace, name := getConfigMapConfig()
+	client := fake.NewSimpleClientset()
+
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromConfigMap(client, namespace, name); err != nil {
+		t.Fatal(err)
+	}
+
+	var conflicts int32 = 3
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if atomic.AddInt32(&conflicts, -1) < 0 {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, name, nil)
+	})
+	if err := g.LoadH28FromConfigMap(client, namespace, name); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint64(&g.w.N) != 2<<36 {
+		t.Fatalf("g.w.N is %d, while it should be %d", atomic.LoadUint64(&g.w.N), uint64(2<<36))
+	}
+}
+
+func TestWUID_LoadH28FromConfigMap_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromConfigMap(nil, "default", "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromConfigMap(fake.NewSimpleClientset(), "", "wuid") == nil {
+		t.Fatal("namespace is not properly checked")
+	}
+	if g.LoadH28FromConfigMap(fake.NewSimpleClientset(), "default", "") == nil {
+		t.Fatal("name is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	namespace, name := getConfigMapConfig()
+	client := fake.NewSimpleClientset()
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromConfigMap(client, namespace, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	namespace, name := getConfigMapConfig()
+	client := fake.NewSimpleClientset()
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromConfigMap(client, namespace, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client kubernetes.Interface
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromConfigMap(client, "default", "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,121 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getKafkaConfig() (string, string) {
+	return "127.0.0.1:9092", "wuid"
+}
+
+func connect(t *testing.T) (*kgo.Client, string) {
+	addr, topic := getKafkaConfig()
+	client, err := kgo.NewClient(kgo.SeedBrokers(addr), kgo.ProduceRequestTimeout(time.Second*3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client, topic
+}
+
+func TestWUID_LoadH28FromKafka(t *testing.T) {
+	client, topic := connect(t)
+
+	var nextValue uint64
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromKafka(client, topic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			nextValue = atomic.LoadUint64(&g.w.N)
+		} else {
+			nextValue = ((nextValue >> 36) + 1) << 36
+		}
+		if atomic.LoadUint64(&g.w.N) != nextValue {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), nextValue, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromKafka_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromKafka(nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromKafka(&kgo.Client{}, "") == nil {
+		t.Fatal("topic is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	client, topic := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromKafka(client, topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	client, topic := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromKafka(client, topic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *kgo.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromKafka(client, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,162 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func openDB(t *testing.T) *leveldb.DB {
+	db, err := leveldb.OpenFile(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestWUID_LoadH28FromLevelDB(t *testing.T) {
+	db := openDB(t)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromLevelDB(db, "wuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromLevelDB_Concurrent(t *testing.T) {
+	db := openDB(t)
+
+	const total = 20
+	var wg sync.WaitGroup
+	var m sync.Mutex
+	seen := make(map[uint64]bool)
+	wg.Add(total)
+	for i := 0; i < total; i++ {
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromLevelDB(db, "wuid"); err != nil {
+				t.Error(err)
+				return
+			}
+			m.Lock()
+			defer m.Unlock()
+			h28 := atomic.LoadUint64(&g.w.N) >> 36
+			if seen[h28] {
+				t.Errorf("duplicated h28: %d", h28)
+			}
+			seen[h28] = true
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWUID_LoadH28FromLevelDB_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromLevelDB(nil, "wuid") == nil {
+		t.Fatal("db is not properly checked")
+	}
+	if g.LoadH28FromLevelDB(openDB(t), "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_ReserveRange(t *testing.T) {
+	db := openDB(t)
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromLevelDB(db, "wuid"); err != nil {
+		t.Fatal(err)
+	}
+
+	n := uint64(1<<36)*3 - 1
+	start, end, err := g.ReserveRange(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 2<<36 || end != start+n-1 {
+		t.Fatalf("ReserveRange does not work as expected: %x, %x", start, end)
+	}
+	if err = g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if h28 := atomic.LoadUint64(&g.w.N) >> 36; h28 != 5 {
+		t.Fatalf("the h28 values reserved by ReserveRange should be skipped. h28: %d", h28)
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	db := openDB(t)
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromLevelDB(db, "wuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	db := openDB(t)
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromLevelDB(db, "wuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	db, _ := leveldb.OpenFile("/var/lib/myapp/wuid", nil)
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromLevelDB(db, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -32,7 +32,7 @@ function printUsage() {
     $colorful && tput setaf 7
 }
 
-dirs='bench callback internal mongo mysql redis'
+dirs='aerospike arango azblob badger bbolt bench callback cassandra clickhouse cockroach consul cosmos couchbase dynamodb elastic etcd failover file firestore gcs hazelcast httploader ignite internal k8s kafka leveldb machine memcached mongo mssql mysql natskv oracle pg quorum redis redisv9 rethinkdb s3 spanner sqlgeneric sqlite surreal tikv vault zookeeper'
 
 for d in $dirs; do
     go vet "github.com/edwingeng/wuid/$d"
@@ -0,0 +1,156 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func skipIfNoHostname(t *testing.T) {
+	if _, err := MachineID(SourceHostname, 8); err != nil {
+		t.Skip(err)
+	}
+}
+
+func TestMachineID(t *testing.T) {
+	skipIfNoHostname(t)
+	name, _ := os.Hostname()
+	id, err := MachineID(SourceHostname, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != hash([]byte(name))&0xFF {
+		t.Fatalf("MachineID does not work as expected: %d", id)
+	}
+	for _, src := range []Source{SourceIP, SourceMAC} {
+		if id, err := MachineID(src, 16); err == nil && id > 0xFFFF {
+			t.Fatalf("MachineID does not work as expected. src: %d, id: %d", src, id)
+		}
+	}
+}
+
+func TestMachineID_Error(t *testing.T) {
+	if _, err := MachineID(SourceHostname, 0); err == nil {
+		t.Fatal("bits is not properly checked")
+	}
+	if _, err := MachineID(SourceHostname, 17); err == nil {
+		t.Fatal("bits is not properly checked")
+	}
+	if _, err := MachineID(Source(42), 8); err == nil {
+		t.Fatal("src is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromMachine(t *testing.T) {
+	skipIfNoHostname(t)
+	id, _ := MachineID(SourceHostname, 8)
+
+	g := NewWUID("default", sl)
+	var last uint64
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromMachine(SourceHostname, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h28 := atomic.LoadUint64(&g.w.N) >> 36
+		if h28>>20 != id {
+			t.Fatalf("the machine ID is %d, while it should be %d. i: %d", h28>>20, id, i)
+		}
+		if i > 0 && h28 == last {
+			t.Fatalf("the h28 does not change. i: %d", i)
+		}
+		last = h28
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromMachine_Error(t *testing.T) {
+	skipIfNoHostname(t)
+	g := NewWUID("default", sl, WithBitLayout(8, 56))
+	if g.LoadH28FromMachine(SourceHostname, 8) == nil {
+		t.Fatal("bits is not properly checked")
+	}
+}
+
+func TestMachineH28(t *testing.T) {
+	const clockBits = 20
+	const clockMask = 1<<clockBits - 1
+	for _, c := range []struct{ id, clock, cur, want uint64 }{
+		{0, 0, 0, 1},
+		{0, clockMask + 1, 0, 1},
+		{0, 0, clockMask, 1},
+		{0, clockMask, clockMask, 1},
+		{0, 0, 1, 2},
+		{0, 5, 0, 5},
+		{1, 0, 0, 1 << clockBits},
+		{1, 7, 1<<clockBits | 7, 1<<clockBits | 8},
+		{1, 3, 1<<clockBits | 7, 1<<clockBits | 8},
+	} {
+		if h28 := machineH28(c.id, clockBits, c.clock, c.cur); h28 != c.want {
+			t.Fatalf("machineH28 does not work as expected. id: %d, clock: %d, cur: %d, h28: %d", c.id, c.clock, c.cur, h28)
+		}
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	skipIfNoHostname(t)
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromMachine(SourceHostname, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	skipIfNoHostname(t)
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromMachine(SourceHostname, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	// Setup
+	g := NewWUID("default", nil, WithBitLayout(40, 24))
+	_ = g.LoadH28FromMachine(SourceIP, 8)
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,111 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getMemcachedConfig() (string, string) {
+	return "127.0.0.1:11211", "wuid"
+}
+
+func TestWUID_LoadH28FromMemcached(t *testing.T) {
+	addr, key := getMemcachedConfig()
+	client := memcache.New(addr)
+	if err := client.Delete(key); err != nil && err != memcache.ErrCacheMiss {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromMemcached(client, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromMemcached_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromMemcached(nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromMemcached(memcache.New("127.0.0.1:11211"), "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	addr, key := getMemcachedConfig()
+	client := memcache.New(addr)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromMemcached(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	addr, key := getMemcachedConfig()
+	client := memcache.New(addr)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromMemcached(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	client := memcache.New("127.0.0.1:11211")
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromMemcached(client, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
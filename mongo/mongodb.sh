@@ -14,4 +14,6 @@ function __EXIT() {
     popd > /dev/null
 }
 
-docker run -d --name wuid-mongo -p 27017:27017 mongo:3.6
+docker run -d --name wuid-mongo -p 27017:27017 mongo:4.0 --replSet rs0
+sleep 5
+docker exec wuid-mongo mongo --quiet --eval "rs.initiate({_id: 'rs0', members: [{_id: 0, host: '127.0.0.1:27017'}]})"
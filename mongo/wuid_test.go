@@ -59,6 +59,31 @@ func TestWUID_LoadH28FromMongo(t *testing.T) {
 	}
 }
 
+func TestWUID_LoadH28FromMongoTxn(t *testing.T) {
+	addr, dbName, coll, docID := getMongoConfig()
+	newClient := func() (*mongo.Client, bool, error) {
+		client, err := connect(addr)
+		return client, true, err
+	}
+
+	var nextValue uint64
+	g := NewWUID(docID, sl)
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromMongoTxn(newClient, dbName, coll, docID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			nextValue = atomic.LoadUint64(&g.w.N)
+		} else {
+			nextValue = ((nextValue >> 36) + 1) << 36
+		}
+		if atomic.LoadUint64(&g.w.N) != nextValue {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), nextValue, i)
+		}
+	}
+}
+
 func TestWUID_LoadH28FromMongo_Error(t *testing.T) {
 	_, dbName, coll, docID := getMongoConfig()
 	g := NewWUID(docID, sl)
@@ -72,6 +97,9 @@ func TestWUID_LoadH28FromMongo_Error(t *testing.T) {
 	if g.LoadH28FromMongo(nil, dbName, coll, "") == nil {
 		t.Fatal("docID is not properly checked")
 	}
+	if g.LoadH28FromMongoTxn(nil, dbName, coll, "") == nil {
+		t.Fatal("docID is not properly checked")
+	}
 }
 
 func TestWUID_Next_Renew(t *testing.T) {
@@ -0,0 +1,9 @@
+IF OBJECT_ID('wuid', 'U') IS NULL
+BEGIN
+    CREATE TABLE wuid (
+        h int NOT NULL,
+        x tinyint NOT NULL DEFAULT 0,
+        CONSTRAINT wuid_pk PRIMARY KEY (x)
+    );
+    INSERT INTO wuid (h, x) VALUES (0, 0);
+END
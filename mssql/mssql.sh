@@ -0,0 +1,25 @@
+#!/usr/bin/env bash
+
+[[ "$TRACE" ]] && set -x
+pushd `dirname "$0"` > /dev/null
+trap __EXIT EXIT
+
+colorful=false
+tput setaf 7 > /dev/null 2>&1
+if [[ $? -eq 0 ]]; then
+    colorful=true
+fi
+
+function __EXIT() {
+    popd > /dev/null
+}
+
+docker run -d --name wuid-mssql -p 1433:1433 -e ACCEPT_EULA=Y -e "MSSQL_SA_PASSWORD=Passw0rd!" mcr.microsoft.com/mssql/server:2022-latest
+[[ $? -ne 0 ]] && exit 1
+
+for ((i=0;i<1000;i++)); do
+	echo "Trying to create the wuid table [$((i+1))]..."
+	docker exec -i wuid-mssql /opt/mssql-tools18/bin/sqlcmd -C -S localhost -U sa -P "Passw0rd!" < db.sql > /dev/null
+	[[ $? -eq 0 ]] && break
+	sleep 1
+done
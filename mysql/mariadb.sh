@@ -19,7 +19,7 @@ docker run -d --name wuid-mysql -p 3306:3306 -e MYSQL_DATABASE=test -e MYSQL_ROO
 
 for ((i=0;i<1000;i++)); do
 	echo "Trying to create the wuid table [$((i+1))]..."
-	mysql -h127.0.0.1 -uroot -ppassword test < db.sql > /dev/null
+	cat db.sql sequence.sql | mysql -h127.0.0.1 -uroot -ppassword test > /dev/null
 	[[ $? -eq 0 ]] && break
 	sleep 1
 done
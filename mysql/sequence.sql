@@ -0,0 +1 @@
+CREATE SEQUENCE IF NOT EXISTS `wuid_seq` START WITH 1 INCREMENT BY 1;
@@ -99,6 +99,41 @@ func TestWUID_LoadH28FromMysql_Error(t *testing.T) {
 	}
 }
 
+func TestWUID_LoadH28FromMariadbSequence(t *testing.T) {
+	addr, user, pass, dbName, _ := getMysqlConfig()
+	newDB := func() (*sql.DB, bool, error) {
+		db, err := connect(addr, user, pass, dbName)
+		return db, true, err
+	}
+
+	var nextValue uint64
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromMariadbSequence(newDB, "wuid_seq")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			nextValue = atomic.LoadUint64(&g.w.N)
+		} else {
+			nextValue = ((nextValue >> 36) + 1) << 36
+		}
+		if atomic.LoadUint64(&g.w.N) != nextValue {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), nextValue, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromMariadbSequence_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromMariadbSequence(nil, "") == nil {
+		t.Fatal("seq is not properly checked")
+	}
+}
+
 func TestWUID_Next_Renew(t *testing.T) {
 	addr, user, pass, dbName, table := getMysqlConfig()
 	db, err := connect(addr, user, pass, dbName)
@@ -0,0 +1,8 @@
+CREATE TABLE wuid (
+    h NUMBER(10) NOT NULL,
+    x NUMBER(3) DEFAULT 0 NOT NULL,
+    CONSTRAINT wuid_pk PRIMARY KEY (x)
+);
+
+INSERT INTO wuid (h, x) VALUES (0, 0);
+COMMIT;
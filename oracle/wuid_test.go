@@ -0,0 +1,153 @@
+package wuid
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	_ "github.com/godror/godror"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func init() {
+	addr, user, pass, service, table := getOracleConfig()
+	db, err := connect(addr, user, pass, service)
+	if err != nil {
+		fmt.Println("oracle connection error: ", err)
+		return
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	_, err = db.Exec(fmt.Sprintf("SELECT 1 FROM %s WHERE x = 0", table))
+	if err != nil {
+		format := "Table '%s' doesn't exist. You can create it with github.com/edwingeng/wuid/oracle/db.sql"
+		panic(fmt.Sprintf(format, table))
+	}
+}
+
+func getOracleConfig() (string, string, string, string, string) {
+	return "127.0.0.1:1521", "system", "password", "FREEPDB1", "wuid"
+}
+
+func connect(addr, user, pass, service string) (*sql.DB, error) {
+	dsn := fmt.Sprintf(`user="%s" password="%s" connectString="%s/%s"`, user, pass, addr, service)
+	return sql.Open("godror", dsn)
+}
+
+func TestWUID_LoadH28FromOracle(t *testing.T) {
+	addr, user, pass, service, table := getOracleConfig()
+	newDB := func() (*sql.DB, bool, error) {
+		db, err := connect(addr, user, pass, service)
+		return db, true, err
+	}
+
+	var nextValue uint64
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromOracle(newDB, table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			nextValue = atomic.LoadUint64(&g.w.N)
+		} else {
+			nextValue = ((nextValue >> 36) + 1) << 36
+		}
+		if atomic.LoadUint64(&g.w.N) != nextValue {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), nextValue, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromOracle_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromOracle(nil, "") == nil {
+		t.Fatal("table is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	addr, user, pass, service, table := getOracleConfig()
+	db, err := connect(addr, user, pass, service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDB := func() (*sql.DB, bool, error) {
+		return db, false, nil
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromOracle(newDB, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	addr, user, pass, service, table := getOracleConfig()
+	db, err := connect(addr, user, pass, service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDB := func() (*sql.DB, bool, error) {
+		return db, false, nil
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromOracle(newDB, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	newDB := func() (*sql.DB, bool, error) {
+		var db *sql.DB
+		// ...
+		return db, true, nil
+	}
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromOracle(newDB, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS wuid (
+    h bigint NOT NULL,
+    x smallint NOT NULL DEFAULT 0,
+    PRIMARY KEY (x)
+);
+
+CREATE SEQUENCE IF NOT EXISTS wuid_seq;
@@ -0,0 +1,218 @@
+package wuid
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+type memStore struct {
+	mu   sync.Mutex
+	h    uint64
+	down bool
+}
+
+func (this *memStore) Store(name string) Store {
+	return Store{Name: name, Advance: func(min uint64) (uint64, error) {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+		if this.down {
+			return 0, errors.New("connection refused")
+		}
+		this.h++
+		if this.h < min {
+			this.h = min
+		}
+		return this.h, nil
+	}}
+}
+
+func newStores(n int) ([]*memStore, []Store) {
+	var ms []*memStore
+	var stores []Store
+	for i := 0; i < n; i++ {
+		m := &memStore{}
+		ms = append(ms, m)
+		stores = append(stores, m.Store(fmt.Sprintf("store%d", i)))
+	}
+	return ms, stores
+}
+
+func TestWUID_LoadH28WithQuorum(t *testing.T) {
+	_, stores := newStores(3)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28WithQuorum(stores...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28WithQuorum_Lagging(t *testing.T) {
+	ms, stores := newStores(3)
+	ms[0].h, ms[1].h, ms[2].h = 10, 7, 3
+
+	g := NewWUID("default", sl)
+	if err := g.LoadH28WithQuorum(stores...); err != nil {
+		t.Fatal(err)
+	}
+	if h28 := atomic.LoadUint64(&g.w.N) >> 36; h28 != 12 {
+		t.Fatalf("the h28 is %d, while it should be 12", h28)
+	}
+	for i, m := range ms {
+		if m.h < 12 {
+			t.Fatalf("store%d has not caught up: %d", i, m.h)
+		}
+	}
+
+	ms, stores = newStores(5)
+	ms[0].h, ms[1].h, ms[2].h, ms[3].h, ms[4].h = 10, 10, 10, 3, 5
+	if err := g.LoadH28WithQuorum(stores...); err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range ms {
+		if m.h != 11 {
+			t.Fatalf("store%d should be advanced to the accepted h28: %d", i, m.h)
+		}
+	}
+}
+
+func TestWUID_LoadH28WithQuorum_Unique(t *testing.T) {
+	ms, stores := newStores(5)
+
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%7 == 0 {
+				// A store rolls back its counter.
+				m := ms[0]
+				m.mu.Lock()
+				m.h /= 2
+				m.mu.Unlock()
+			}
+			g := NewWUID("default", sl)
+			if err := g.LoadH28WithQuorum(stores...); err != nil {
+				return
+			}
+			h28 := atomic.LoadUint64(&g.w.N) >> 36
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[h28] {
+				t.Errorf("duplicate h28: %d", h28)
+			}
+			seen[h28] = true
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWUID_LoadH28WithQuorum_Unavailable(t *testing.T) {
+	ms, stores := newStores(3)
+
+	g := NewWUID("default", sl)
+	ms[0].down = true
+	if err := g.LoadH28WithQuorum(stores...); err != nil {
+		t.Fatal("a minority of stores being down should be tolerated")
+	}
+	ms[1].down = true
+	if g.LoadH28WithQuorum(stores...) == nil {
+		t.Fatal("LoadH28WithQuorum should fail without a majority")
+	}
+}
+
+func TestWUID_LoadH28WithQuorum_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	_, stores := newStores(2)
+	if g.LoadH28WithQuorum(stores...) == nil {
+		t.Fatal("the number of stores is not properly checked")
+	}
+	_, stores = newStores(3)
+	stores[1].Advance = nil
+	if g.LoadH28WithQuorum(stores...) == nil {
+		t.Fatal("Advance is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, stores := newStores(3)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28WithQuorum(stores...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, stores := newStores(3)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28WithQuorum(stores...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var advanceRedis, advanceMysql, advanceEtcd func(min uint64) (uint64, error)
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28WithQuorum(
+		Store{Name: "redis", Advance: advanceRedis},
+		Store{Name: "mysql", Advance: advanceMysql},
+		Store{Name: "etcd", Advance: advanceEtcd},
+	)
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -1,9 +1,11 @@
 package wuid
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"math/rand"
+	"net"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -13,6 +15,7 @@ import (
 )
 
 var bRedisCluster = flag.Bool("cluster", false, "")
+var bRedisSentinel = flag.Bool("sentinel", false, "")
 
 type simpleLogger struct{}
 
@@ -29,6 +32,13 @@ func getRedisClusterConfig() ([]string, string, string) {
 	return []string{"127.0.0.1:6379", "127.0.0.1:6380", "127.0.0.1:6381"}, "", "wuid"
 }
 
+func getRedisSentinelConfig() (*redis.FailoverOptions, string) {
+	return &redis.FailoverOptions{
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{"127.0.0.1:26379"},
+	}, "wuid"
+}
+
 func TestWUID_LoadH28FromRedis(t *testing.T) {
 	if *bRedisCluster {
 		return
@@ -117,6 +127,206 @@ func TestWUID_LoadH28FromRedisCluster(t *testing.T) {
 	}
 }
 
+func TestWUID_LoadH28FromRedisCluster_Universal(t *testing.T) {
+	if !*bRedisCluster {
+		return
+	}
+
+	addrs, pass, key := getRedisClusterConfig()
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    addrs,
+		Password: pass,
+	})
+	defer func() {
+		_ = client.Close()
+	}()
+	_, err := client.Del(HashTag(key)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newClient := func() (redis.UniversalClient, bool, error) {
+		return client, false, nil
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err = g.LoadH28FromRedisCluster(newClient, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromRedisCluster_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromRedisCluster(nil, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromRedisSentinel(t *testing.T) {
+	if !*bRedisSentinel {
+		return
+	}
+
+	opts, key := getRedisSentinelConfig()
+	client := redis.NewFailoverClient(opts)
+	defer func() {
+		_ = client.Close()
+	}()
+	_, err := client.Del(key).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromRedisSentinel(opts, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+	}
+}
+
+func TestWUID_LoadH28FromRedisSentinel_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromRedisSentinel(nil, "wuid") == nil {
+		t.Fatal("opts is not properly checked")
+	}
+	if g.LoadH28FromRedisSentinel(&redis.FailoverOptions{SentinelAddrs: []string{"127.0.0.1:26379"}}, "wuid") == nil {
+		t.Fatal("opts.MasterName is not properly checked")
+	}
+	if g.LoadH28FromRedisSentinel(&redis.FailoverOptions{MasterName: "mymaster"}, "wuid") == nil {
+		t.Fatal("opts.SentinelAddrs is not properly checked")
+	}
+	opts, _ := getRedisSentinelConfig()
+	if g.LoadH28FromRedisSentinel(opts, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestIsFailoverError(t *testing.T) {
+	if !isFailoverError(errors.New("READONLY You can't write against a read only replica.")) {
+		t.Fatal("READONLY should be treated as a failover error")
+	}
+	if !isFailoverError(&net.OpError{Op: "dial", Err: errors.New("foo")}) {
+		t.Fatal("a network error should be treated as a failover error")
+	}
+	if isFailoverError(errors.New("ERR value is not an integer or out of range")) {
+		t.Fatal("ERR should not be treated as a failover error")
+	}
+}
+
+func TestWUID_LastAllocation(t *testing.T) {
+	if *bRedisCluster {
+		return
+	}
+
+	addr, pass, key := getRedisConfig()
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: pass,
+	})
+	defer func() {
+		_ = client.Close()
+	}()
+	newClient := func() (redis.Cmdable, bool, error) {
+		return client, false, nil
+	}
+
+	g := NewWUID("default", sl)
+	if _, ok := g.LastAllocation(); ok {
+		t.Fatal("LastAllocation should return false before the first load")
+	}
+	if err := g.LoadH28FromRedis(newClient, key); err != nil {
+		t.Fatal(err)
+	}
+	a, ok := g.LastAllocation()
+	if !ok || a.H28 != atomic.LoadUint64(&g.w.N)>>36 {
+		t.Fatalf("LastAllocation does not work as expected: %+v", a)
+	}
+	meta, err := client.HGetAll(MetaKey(key)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta["h28"] != fmt.Sprint(a.H28) || meta["host"] != a.Host || meta["time"] != fmt.Sprint(a.Time.Unix()) {
+		t.Fatalf("the metadata does not match the allocation. meta: %v, allocation: %+v", meta, a)
+	}
+}
+
+func TestWUID_LoadH28FromRedis_Bound(t *testing.T) {
+	if *bRedisCluster {
+		return
+	}
+
+	addr, pass, key := getRedisConfig()
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: pass,
+	})
+	defer func() {
+		_ = client.Close()
+	}()
+	if err := client.Set(key, 0x00FFFFFF, 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	newClient := func() (redis.Cmdable, bool, error) {
+		return client, false, nil
+	}
+
+	g := NewWUID("default", sl, WithSection(1))
+	if g.LoadH28FromRedis(newClient, key) == nil {
+		t.Fatal("the h28 bound is not properly checked")
+	}
+	n, err := client.Get(key).Int64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0x00FFFFFF {
+		t.Fatalf("the counter should not be changed when it hits the bound. n: %d", n)
+	}
+}
+
+func TestMetaKey(t *testing.T) {
+	cases := map[string]string{
+		"wuid":       "{wuid}:meta",
+		"{wuid}":     "{wuid}:meta",
+		"{wuid}:foo": "{wuid}:foo:meta",
+	}
+	for key, expected := range cases {
+		if actual := MetaKey(key); actual != expected {
+			t.Fatalf("MetaKey does not work as expected. key: %s, expected: %s, actual: %s", key, expected, actual)
+		}
+	}
+}
+
+func TestHashTag(t *testing.T) {
+	cases := map[string]string{
+		"wuid":       "{wuid}",
+		"{wuid}":     "{wuid}",
+		"{wuid}:foo": "{wuid}:foo",
+		"foo{bar}":   "foo{bar}",
+		"foo{}":      "{foo{}}",
+		"foo{":       "{foo{}",
+	}
+	for key, expected := range cases {
+		if actual := HashTag(key); actual != expected {
+			t.Fatalf("HashTag does not work as expected. key: %s, expected: %s, actual: %s", key, expected, actual)
+		}
+	}
+}
+
 func TestWUID_Next_Renew(t *testing.T) {
 	if *bRedisCluster {
 		return
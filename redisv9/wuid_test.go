@@ -0,0 +1,386 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/redis/go-redis/v9"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getRedisConfig() (string, string, string) {
+	return "127.0.0.1:6379", "", "wuid"
+}
+
+func getValkeyConfig() (string, string) {
+	return "127.0.0.1:6390", "wuid"
+}
+
+func getDragonflyConfig() (string, string) {
+	return "127.0.0.1:6391", "wuid"
+}
+
+func connect(t *testing.T) *redis.Client {
+	addr, pass, key := getRedisConfig()
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: pass,
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	if err := client.Del(context.Background(), key).Err(); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestWUID_LoadH28FromRedis(t *testing.T) {
+	addr, pass, key := getRedisConfig()
+	_ = connect(t)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromRedis(context.Background(), addr, key, WithCredentials("", pass))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromRedis_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	ctx := context.Background()
+	if g.LoadH28FromRedis(nil, "127.0.0.1:6379", "wuid") == nil {
+		t.Fatal("ctx is not properly checked")
+	}
+	if g.LoadH28FromRedis(ctx, "", "wuid") == nil {
+		t.Fatal("addr is not properly checked")
+	}
+	if g.LoadH28FromRedis(ctx, "127.0.0.1:6379", "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+	if g.LoadH28FromValkey(ctx, "", "wuid") == nil {
+		t.Fatal("addr is not properly checked")
+	}
+	if g.LoadH28FromDragonfly(ctx, "", "wuid") == nil {
+		t.Fatal("addr is not properly checked")
+	}
+	if g.LoadH28FromRedisClient(ctx, nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromRedisClient(ctx, &redis.Client{}, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_LoadH28FromRedisClient(t *testing.T) {
+	_, _, key := getRedisConfig()
+	client := connect(t)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromRedisClient(context.Background(), client, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+	}
+}
+
+func cleanUpLeases(t *testing.T, client *redis.Client, key string) {
+	ctx := context.Background()
+	keys, err := scanKeys(ctx, client, key+":*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) > 0 {
+		if err = client.Del(ctx, keys...).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWUID_LoadH28FromRedisClientWithLease(t *testing.T) {
+	_, _, key := getRedisConfig()
+	client := connect(t)
+	cleanUpLeases(t, client, key)
+	ctx := context.Background()
+
+	g := NewWUID("default", sl)
+	defer g.Close()
+	for i := 0; i < 10; i++ {
+		err := g.LoadH28FromRedisClientWithLease(ctx, client, key, time.Second*10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+	}
+
+	ttl, err := client.PTTL(ctx, LeaseKey(key, 10)).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttl <= 0 {
+		t.Fatal("the h28 in use should be recorded with a TTL")
+	}
+	done, err := scanKeys(ctx, client, key+":done:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 9 {
+		t.Fatalf("there should be 9 done records, while there are %d", len(done))
+	}
+	abandoned, err := AbandonedH28s(ctx, client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 0 {
+		t.Fatalf("no h28 should be abandoned: %v", abandoned)
+	}
+}
+
+func TestWUID_Close_Lease(t *testing.T) {
+	_, _, key := getRedisConfig()
+	client := connect(t)
+	cleanUpLeases(t, client, key)
+	ctx := context.Background()
+
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromRedisClientWithLease(ctx, client, key, time.Second*10); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := client.Exists(ctx, DoneKey(key, 1)).Result(); err != nil || n != 1 {
+		t.Fatal("the h28 in use should be retired when the WUID is closed")
+	}
+}
+
+func TestAbandonedH28s(t *testing.T) {
+	_, _, key := getRedisConfig()
+	client := connect(t)
+	cleanUpLeases(t, client, key)
+	ctx := context.Background()
+
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromRedisClientWithLease(ctx, client, key, time.Second*10); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a dead process
+	g.lease.cancel()
+	if err := client.Del(ctx, LeaseKey(key, 1)).Err(); err != nil {
+		t.Fatal(err)
+	}
+	abandoned, err := AbandonedH28s(ctx, client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abandoned) != 1 || abandoned[0] != 1 {
+		t.Fatalf("h28 1 should be abandoned: %v", abandoned)
+	}
+}
+
+func TestWUID_LoadH28FromRedisClientWithLease_Error(t *testing.T) {
+	ctx := context.Background()
+	g := NewWUID("default", sl)
+	if g.LoadH28FromRedisClientWithLease(ctx, nil, "wuid", time.Second*10) == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromRedisClientWithLease(ctx, &redis.Client{}, "", time.Second*10) == nil {
+		t.Fatal("key is not properly checked")
+	}
+	if g.LoadH28FromRedisClientWithLease(ctx, &redis.Client{}, "wuid", time.Millisecond) == nil {
+		t.Fatal("ttl is not properly checked")
+	}
+	g2 := NewWUID("default", sl, WithPrefetch())
+	if g2.LoadH28FromRedisClientWithLease(ctx, &redis.Client{}, "wuid", time.Second*10) == nil {
+		t.Fatal("WithPrefetch is not properly checked")
+	}
+	if _, err := AbandonedH28s(ctx, nil, "wuid"); err == nil {
+		t.Fatal("client is not properly checked")
+	}
+}
+
+func TestLeaseKey(t *testing.T) {
+	if LeaseKey("wuid", 42) != "wuid:lease:42" {
+		t.Fatal("LeaseKey does not work as expected")
+	}
+	if DoneKey("wuid", 42) != "wuid:done:42" {
+		t.Fatal("DoneKey does not work as expected")
+	}
+}
+
+func TestNewUsageReporter(t *testing.T) {
+	_, _, key := getRedisConfig()
+	client := connect(t)
+	ctx := context.Background()
+	if err := client.Del(ctx, UsageKey(key, "default", "host-1")).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithUsageReport(NewUsageReporter(client, key, "host-1")))
+	if err := g.LoadH28FromRedisClient(ctx, client, key); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		g.Next()
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := client.HGetAll(ctx, UsageKey(key, "default", "host-1")).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["h28"] != "1" || m["consumed"] != "10" {
+		t.Fatalf("the usage is not recorded as expected: %v", m)
+	}
+}
+
+func TestUsageKey(t *testing.T) {
+	if UsageKey("wuid", "default", "host-1") != "wuid:usage:default:host-1" {
+		t.Fatal("UsageKey does not work as expected")
+	}
+}
+
+func TestWUID_LoadH28FromRedis_Canceled(t *testing.T) {
+	addr, _, key := getRedisConfig()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	g := NewWUID("default", sl)
+	if g.LoadH28FromRedis(ctx, addr, key) == nil {
+		t.Fatal("a canceled ctx should abort the load")
+	}
+}
+
+func testLoadH28(t *testing.T, client *redis.Client, key string, load func(g *WUID) error) {
+	defer func() {
+		_ = client.Close()
+	}()
+	if err := client.Del(context.Background(), key).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		if err := load(g); err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+	}
+}
+
+func TestWUID_LoadH28FromValkey(t *testing.T) {
+	addr, key := getValkeyConfig()
+	testLoadH28(t, NewValkeyClient(addr), key, func(g *WUID) error {
+		return g.LoadH28FromValkey(context.Background(), addr, key)
+	})
+}
+
+func TestWUID_LoadH28FromDragonfly(t *testing.T) {
+	addr, key := getDragonflyConfig()
+	testLoadH28(t, NewDragonflyClient(addr), key, func(g *WUID) error {
+		return g.LoadH28FromDragonfly(context.Background(), addr, key)
+	})
+}
+
+func TestWUID_LoadH28FromDragonfly_RESP2(t *testing.T) {
+	addr, key := getDragonflyConfig()
+	testLoadH28(t, NewDragonflyClient(addr), key, func(g *WUID) error {
+		return g.LoadH28FromDragonfly(context.Background(), addr, key, WithProtocol(2))
+	})
+}
+
+func TestNewClients(t *testing.T) {
+	o1 := NewValkeyClient("127.0.0.1:6390", WithDB(2)).Options()
+	if o1.Protocol != 3 || o1.DB != 2 || o1.DisableIndentity {
+		t.Fatal("NewValkeyClient does not work as expected")
+	}
+	o2 := NewDragonflyClient("127.0.0.1:6391", WithProtocol(2)).Options()
+	if o2.Protocol != 2 || !o2.DisableIndentity {
+		t.Fatal("NewDragonflyClient does not work as expected")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	addr, pass, key := getRedisConfig()
+	_ = connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromRedis(context.Background(), addr, key, WithCredentials("", pass))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	addr, pass, key := getRedisConfig()
+	_ = connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromRedis(context.Background(), addr, key, WithCredentials("", pass))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromRedis(context.Background(), "127.0.0.1:6379", "wuid",
+		WithCredentials("", "password"), WithDB(0))
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
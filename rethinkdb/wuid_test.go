@@ -0,0 +1,139 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getRethinkDBConfig() (string, string, string, string) {
+	return "127.0.0.1:28015", "test", "wuid", "default"
+}
+
+func connect(t *testing.T) *r.Session {
+	addr, database, table, _ := getRethinkDBConfig()
+	session, err := r.Connect(r.ConnectOpts{Address: addr, Database: database, Timeout: time.Second * 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = session.Close() })
+
+	n, err := r.TableList().Contains(table).Run(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exists bool
+	if err = n.One(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		if _, err = r.TableCreate(table).RunWrite(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return session
+}
+
+func TestWUID_LoadH28FromRethinkDB(t *testing.T) {
+	_, _, table, tag := getRethinkDBConfig()
+	session := connect(t)
+	if _, err := r.Table(table).Get(tag).Delete().RunWrite(session); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromRethinkDB(session, table, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromRethinkDB_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromRethinkDB(nil, "wuid", "default") == nil {
+		t.Fatal("session is not properly checked")
+	}
+	if g.LoadH28FromRethinkDB(&r.Session{}, "", "default") == nil {
+		t.Fatal("table is not properly checked")
+	}
+	if g.LoadH28FromRethinkDB(&r.Session{}, "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, _, table, tag := getRethinkDBConfig()
+	session := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromRethinkDB(session, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, _, table, tag := getRethinkDBConfig()
+	session := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromRethinkDB(session, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var session *r.Session
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromRethinkDB(session, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,202 @@
+package wuid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getS3Config() (string, string, string) {
+	return "http://127.0.0.1:9000", "wuid", "default"
+}
+
+func connect(endpoint, bucket string) (*s3.Client, error) {
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("minioadmin", "minioadmin", ""),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	_, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	var owned *types.BucketAlreadyOwnedByYou
+	if err != nil && !errors.As(err, &owned) {
+		return nil, err
+	}
+	return client, nil
+}
+
+func TestWUID_LoadH28FromS3(t *testing.T) {
+	endpoint, bucket, key := getS3Config()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromS3(client, bucket, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromS3_Concurrent(t *testing.T) {
+	endpoint, bucket, key := getS3Config()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := NewWUID("default", sl)
+			if err := g.LoadH28FromS3(client, bucket, key); err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			seen[atomic.LoadUint64(&g.w.N)>>36] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if !t.Failed() && len(seen) != n {
+		t.Fatalf("duplicate h28 detected: %d unique values out of %d", len(seen), n)
+	}
+}
+
+func TestWUID_LoadH28FromS3_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromS3(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromS3(&s3.Client{}, "", "default") == nil {
+		t.Fatal("bucket is not properly checked")
+	}
+	if g.LoadH28FromS3(&s3.Client{}, "wuid", "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	if isConflict(nil) {
+		t.Fatal("nil should not be a conflict")
+	}
+	if isConflict(errors.New("PreconditionFailed")) {
+		t.Fatal("a plain error should not be a conflict")
+	}
+	for _, code := range []string{"PreconditionFailed", "ConditionalRequestConflict"} {
+		err := fmt.Errorf("put: %w", &smithy.GenericAPIError{Code: code})
+		if !isConflict(err) {
+			t.Fatalf("%s should be a conflict", code)
+		}
+	}
+	if isConflict(&smithy.GenericAPIError{Code: "AccessDenied"}) {
+		t.Fatal("AccessDenied should not be a conflict")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	endpoint, bucket, key := getS3Config()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromS3(client, bucket, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	endpoint, bucket, key := getS3Config()
+	client, err := connect(endpoint, bucket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromS3(client, bucket, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *s3.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromS3(client, "my-bucket", "wuid/default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,4 @@
+CREATE TABLE wuid (
+  tag STRING(MAX) NOT NULL,
+  h INT64 NOT NULL
+) PRIMARY KEY (tag)
@@ -0,0 +1,23 @@
+#!/usr/bin/env bash
+
+[[ "$TRACE" ]] && set -x
+pushd `dirname "$0"` > /dev/null
+trap __EXIT EXIT
+
+colorful=false
+tput setaf 7 > /dev/null 2>&1
+if [[ $? -eq 0 ]]; then
+    colorful=true
+fi
+
+function __EXIT() {
+    popd > /dev/null
+}
+
+docker run -d --name wuid-spanner -p 9010:9010 -p 9020:9020 gcr.io/cloud-spanner-emulator/emulator
+sleep 3
+
+curl -s -X POST http://127.0.0.1:9020/v1/projects/test/instances \
+    -d '{"instanceId": "test", "instance": {"config": "emulator-config", "nodeCount": 1}}'
+curl -s -X POST http://127.0.0.1:9020/v1/projects/test/instances/test/databases \
+    -d "{\"createStatement\": \"CREATE DATABASE \`test\`\", \"extraStatements\": [\"$(tr '\n' ' ' < db.sql)\"]}"
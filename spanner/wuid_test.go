@@ -0,0 +1,139 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getSpannerConfig() (string, string, string, string) {
+	return "127.0.0.1:9010", "projects/test/instances/test/databases/test", "wuid", "default"
+}
+
+func connect(emulator, database string) (*spanner.Client, error) {
+	if err := os.Setenv("SPANNER_EMULATOR_HOST", emulator); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	return spanner.NewClient(ctx, database)
+}
+
+func TestWUID_LoadH28FromSpanner(t *testing.T) {
+	emulator, database, table, tag := getSpannerConfig()
+	client, err := connect(emulator, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	_, err = client.Apply(context.Background(), []*spanner.Mutation{spanner.Delete(table, spanner.Key{tag})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err = g.LoadH28FromSpanner(client, table, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromSpanner_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromSpanner(nil, "wuid", "default") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromSpanner(&spanner.Client{}, "", "default") == nil {
+		t.Fatal("table is not properly checked")
+	}
+	if g.LoadH28FromSpanner(&spanner.Client{}, "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	emulator, database, table, tag := getSpannerConfig()
+	client, err := connect(emulator, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromSpanner(client, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	emulator, database, table, tag := getSpannerConfig()
+	client, err := connect(emulator, database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromSpanner(client, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *spanner.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromSpanner(client, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,5 @@
+CREATE TABLE wuid (
+    x INTEGER NOT NULL PRIMARY KEY,
+    h BIGINT NOT NULL
+);
+INSERT INTO wuid (x, h) VALUES (0, 0);
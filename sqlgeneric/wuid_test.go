@@ -0,0 +1,174 @@
+package wuid
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	_ "modernc.org/sqlite"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func setup(t *testing.T) NewDB {
+	path := filepath.Join(t.TempDir(), "wuid.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE wuid (x INTEGER PRIMARY KEY, h INTEGER NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO wuid (x, h) VALUES (0, 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func() (*sql.DB, bool, error) {
+		db, err := sql.Open("sqlite", path)
+		return db, true, err
+	}
+}
+
+func TestWUID_LoadH28FromDB(t *testing.T) {
+	newDB := setup(t)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromDB(newDB, "wuid", SQLite)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromDB_CustomDialect(t *testing.T) {
+	newDB := setup(t)
+
+	dialect := Dialect{
+		Update: "UPDATE %s SET h = h + 2 WHERE x = ?",
+		Select: "SELECT h * 10 FROM %s WHERE x = ?",
+	}
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromDB(newDB, "wuid", dialect); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadUint64(&g.w.N)>>36 != 20 {
+		t.Fatalf("the custom dialect is not used: %d", atomic.LoadUint64(&g.w.N)>>36)
+	}
+}
+
+func TestWUID_LoadH28FromDB_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromDB(nil, "", SQLite) == nil {
+		t.Fatal("table is not properly checked")
+	}
+
+	path := filepath.Join(t.TempDir(), "wuid.db")
+	newDB := func() (*sql.DB, bool, error) {
+		db, err := sql.Open("sqlite", path)
+		return db, true, err
+	}
+	db, _, _ := newDB()
+	_, err := db.Exec("CREATE TABLE wuid (x INTEGER PRIMARY KEY, h INTEGER NOT NULL)")
+	_ = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.LoadH28FromDB(newDB, "wuid", SQLite) == nil {
+		t.Fatal("the missing row is not properly checked")
+	}
+}
+
+func TestDialect_rebind(t *testing.T) {
+	query := "UPDATE wuid SET h = h + 1 WHERE x = ? AND y = ?"
+	tests := []struct {
+		placeholder Placeholder
+		expected    string
+	}{
+		{Question, "UPDATE wuid SET h = h + 1 WHERE x = ? AND y = ?"},
+		{Dollar, "UPDATE wuid SET h = h + 1 WHERE x = $1 AND y = $2"},
+		{Colon, "UPDATE wuid SET h = h + 1 WHERE x = :1 AND y = :2"},
+		{AtP, "UPDATE wuid SET h = h + 1 WHERE x = @p1 AND y = @p2"},
+	}
+	for _, tt := range tests {
+		if s := (Dialect{Placeholder: tt.placeholder}).rebind(query); s != tt.expected {
+			t.Fatalf("rebind(%d) returned %q, while it should return %q", tt.placeholder, s, tt.expected)
+		}
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	newDB := setup(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromDB(newDB, "wuid", SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	newDB := setup(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromDB(newDB, "wuid", SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	newDB := func() (*sql.DB, bool, error) {
+		db, err := sql.Open("mysql", "root:@tcp(127.0.0.1:3306)/test")
+		return db, true, err
+	}
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromDB(newDB, "wuid", MySQL)
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
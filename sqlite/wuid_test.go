@@ -0,0 +1,136 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func TestWUID_LoadH28FromSqlite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.db")
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromSqlite(path, "wuid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromSqlite_Restart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.db")
+
+	g1 := NewWUID("default", sl)
+	if err := g1.LoadH28FromSqlite(path, "wuid"); err != nil {
+		t.Fatal(err)
+	}
+	g2 := NewWUID("default", sl)
+	if err := g2.LoadH28FromSqlite(path, "wuid"); err != nil {
+		t.Fatal(err)
+	}
+	if g1.Next()>>36 == g2.Next()>>36 {
+		t.Fatal("the h28 should change across restarts")
+	}
+}
+
+func TestWUID_LoadH28FromSqlite_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromSqlite("", "wuid") == nil {
+		t.Fatal("path is not properly checked")
+	}
+	if g.LoadH28FromSqlite("wuid.db", "") == nil {
+		t.Fatal("table is not properly checked")
+	}
+}
+
+func TestWUID_ReserveRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.db")
+	g := NewWUID("default", sl)
+	if err := g.LoadH28FromSqlite(path, "wuid"); err != nil {
+		t.Fatal(err)
+	}
+
+	n := uint64(1<<36)*3 - 1
+	start, end, err := g.ReserveRange(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 2<<36 || end != start+n-1 {
+		t.Fatalf("ReserveRange does not work as expected: %x, %x", start, end)
+	}
+	if err = g.RenewNow(); err != nil {
+		t.Fatal(err)
+	}
+	if h28 := atomic.LoadUint64(&g.w.N) >> 36; h28 != 5 {
+		t.Fatalf("the h28 values reserved by ReserveRange should be skipped. h28: %d", h28)
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.db")
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromSqlite(path, "wuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wuid.db")
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromSqlite(path, "wuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromSqlite("/var/lib/myapp/wuid.db", "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
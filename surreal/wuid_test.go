@@ -0,0 +1,139 @@
+package wuid
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/surrealdb/surrealdb.go"
+	"github.com/surrealdb/surrealdb.go/pkg/models"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getSurrealConfig() (string, string, string) {
+	return "ws://127.0.0.1:8000", "wuid", "default"
+}
+
+func connect(t *testing.T) *surrealdb.DB {
+	addr, _, _ := getSurrealConfig()
+	db, err := surrealdb.New(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err = db.SignIn(&surrealdb.Auth{Username: "root", Password: "root"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Use("test", "test"); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func reset(t *testing.T, db *surrealdb.DB) {
+	_, table, tag := getSurrealConfig()
+	if _, err := surrealdb.Delete[interface{}](db, models.NewRecordID(table, tag)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWUID_LoadH28FromSurreal(t *testing.T) {
+	_, table, tag := getSurrealConfig()
+	db := connect(t)
+	reset(t, db)
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err := g.LoadH28FromSurreal(db, table, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromSurreal_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromSurreal(nil, "wuid", "default") == nil {
+		t.Fatal("db is not properly checked")
+	}
+	if g.LoadH28FromSurreal(&surrealdb.DB{}, "", "default") == nil {
+		t.Fatal("table is not properly checked")
+	}
+	if g.LoadH28FromSurreal(&surrealdb.DB{}, "wuid", "") == nil {
+		t.Fatal("tag is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	_, table, tag := getSurrealConfig()
+	db := connect(t)
+	reset(t, db)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromSurreal(db, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	_, table, tag := getSurrealConfig()
+	db := connect(t)
+	reset(t, db)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromSurreal(db, table, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var db *surrealdb.DB
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromSurreal(db, "wuid", "default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
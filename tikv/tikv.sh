@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+
+[[ "$TRACE" ]] && set -x
+pushd `dirname "$0"` > /dev/null
+trap __EXIT EXIT
+
+colorful=false
+tput setaf 7 > /dev/null 2>&1
+if [[ $? -eq 0 ]]; then
+    colorful=true
+fi
+
+function __EXIT() {
+    popd > /dev/null
+}
+
+docker run -d --name wuid-pd --net host pingcap/pd:v7.5.0 --name=pd --client-urls=http://127.0.0.1:2379 --peer-urls=http://127.0.0.1:2380
+[[ $? -ne 0 ]] && exit 1
+docker run -d --name wuid-tikv --net host pingcap/tikv:v7.5.0 --addr=127.0.0.1:20160 --pd=127.0.0.1:2379
@@ -0,0 +1,153 @@
+package wuid
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/tikv/client-go/v2/txnkv"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getTikvConfig() ([]string, string) {
+	return []string{"127.0.0.1:2379"}, "wuid"
+}
+
+func connect(pdAddrs []string) (*txnkv.Client, error) {
+	// txnkv.NewClient keeps retrying while PD is unreachable, so fail fast here
+	conn, err := net.DialTimeout("tcp", pdAddrs[0], time.Second*3)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.Close()
+	return txnkv.NewClient(pdAddrs)
+}
+
+func deleteKey(client *txnkv.Client, key string) error {
+	txn, err := client.Begin()
+	if err != nil {
+		return err
+	}
+	if err = txn.Delete([]byte(key)); err != nil {
+		return err
+	}
+	return txn.Commit(context.Background())
+}
+
+func TestWUID_LoadH28FromTikv(t *testing.T) {
+	pdAddrs, key := getTikvConfig()
+	client, err := connect(pdAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	if err = deleteKey(client, key); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 1000; i++ {
+		err = g.LoadH28FromTikv(client, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromTikv_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromTikv(nil, "wuid") == nil {
+		t.Fatal("client is not properly checked")
+	}
+	if g.LoadH28FromTikv(&txnkv.Client{}, "") == nil {
+		t.Fatal("key is not properly checked")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	pdAddrs, key := getTikvConfig()
+	client, err := connect(pdAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	g := NewWUID("default", sl)
+	err = g.LoadH28FromTikv(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	pdAddrs, key := getTikvConfig()
+	client, err := connect(pdAddrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	g := NewWUID("default", sl, WithSection(15))
+	err = g.LoadH28FromTikv(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *txnkv.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromTikv(client, "wuid")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}
@@ -0,0 +1,143 @@
+package wuid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/edwingeng/wuid/internal"
+	"github.com/hashicorp/vault/api"
+)
+
+type simpleLogger struct{}
+
+func (this *simpleLogger) Info(args ...interface{}) {}
+func (this *simpleLogger) Warn(args ...interface{}) {}
+
+var sl = &simpleLogger{}
+
+func getVaultConfig() (string, string, string, string) {
+	return "http://127.0.0.1:8200", "root", "secret", "wuid/default"
+}
+
+func connect(t *testing.T) (*api.KVv2, string) {
+	addr, token, mount, path := getVaultConfig()
+	cfg := api.DefaultConfig()
+	cfg.Address = addr
+	cfg.Timeout = time.Second * 3
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.SetToken(token)
+	return client.KVv2(mount), path
+}
+
+func TestWUID_LoadH28FromVault(t *testing.T) {
+	kv, path := connect(t)
+	if err := kv.DeleteMetadata(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewWUID("default", sl)
+	for i := 0; i < 100; i++ {
+		err := g.LoadH28FromVault(kv, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := (uint64(i) + 1) << 36
+		if atomic.LoadUint64(&g.w.N) != v {
+			t.Fatalf("g.w.N is %d, while it should be %d. i: %d", atomic.LoadUint64(&g.w.N), v, i)
+		}
+		for j := 0; j < rand.Intn(10); j++ {
+			g.Next()
+		}
+	}
+}
+
+func TestWUID_LoadH28FromVault_Error(t *testing.T) {
+	g := NewWUID("default", sl)
+	if g.LoadH28FromVault(nil, "wuid/default") == nil {
+		t.Fatal("kv is not properly checked")
+	}
+	if g.LoadH28FromVault(&api.KVv2{}, "") == nil {
+		t.Fatal("path is not properly checked")
+	}
+}
+
+func TestIsConflict(t *testing.T) {
+	if isConflict(nil) {
+		t.Fatal("nil should not be a conflict")
+	}
+	if isConflict(errors.New("check-and-set")) {
+		t.Fatal("a plain error should not be a conflict")
+	}
+	err := fmt.Errorf("error writing secret: %w", &api.ResponseError{
+		StatusCode: 400,
+		Errors:     []string{"check-and-set parameter did not match the current version"},
+	})
+	if !isConflict(err) {
+		t.Fatal("a cas mismatch should be a conflict")
+	}
+	if isConflict(&api.ResponseError{StatusCode: 403, Errors: []string{"permission denied"}}) {
+		t.Fatal("403 should not be a conflict")
+	}
+}
+
+func TestWUID_Next_Renew(t *testing.T) {
+	kv, path := connect(t)
+
+	g := NewWUID("default", sl)
+	err := g.LoadH28FromVault(kv, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n1 := g.Next()
+	kk := ((internal.CriticalValue + internal.RenewInterval) & ^internal.RenewInterval) - 1
+
+	g.w.Reset((n1 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n2 := g.Next()
+
+	g.w.Reset((n2 >> 36 << 36) | kk)
+	g.Next()
+	time.Sleep(time.Millisecond * 200)
+	n3 := g.Next()
+
+	if n2>>36 == n1>>36 || n3>>36 == n2>>36 {
+		t.Fatalf("the renew mechanism does not work as expected: %x, %x, %x", n1>>36, n2>>36, n3>>36)
+	}
+}
+
+func TestWithSection(t *testing.T) {
+	kv, path := connect(t)
+
+	g := NewWUID("default", sl, WithSection(15))
+	err := g.LoadH28FromVault(kv, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Next()>>60 != 15 {
+		t.Fatal("WithSection does not work as expected")
+	}
+}
+
+func Example() {
+	var client *api.Client
+	// ...
+
+	// Setup
+	g := NewWUID("default", nil)
+	_ = g.LoadH28FromVault(client.KVv2("secret"), "wuid/default")
+
+	// Generate
+	for i := 0; i < 10; i++ {
+		fmt.Printf("%#016x\n", g.Next())
+	}
+}